@@ -1,138 +1,438 @@
 package circle
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
+// CircleCI is a client of the CircleCI API.
+//
+// The methods of the original API come in pairs: X, and XContext, which is
+// like X but takes a context for the underlying request. Methods added since
+// take a context as their first argument and have no variant without one.
 type CircleCI interface {
 	// Provides information about the authenticated user.
 	// https://circleci.com/docs/api#user
 	// https://circleci.com/api/v1/me
 	Me() (Me, error)
 
+	// MeContext is like Me but uses ctx for the underlying request.
+	MeContext(ctx context.Context) (Me, error)
+
 	// Provides information about projects followed by the authenticated user.
 	//
 	// https://circleci.com/docs/api#projects
 	// https://circleci.com/api/v1/projects
 	Projects() ([]Project, error)
 
+	// ProjectsContext is like Projects but uses ctx for the underlying request.
+	ProjectsContext(ctx context.Context) ([]Project, error)
+
 	// Provides Build summary for each of the last 30 recent builds, ordered by BuildNum.
 	//
 	// https://circleci.com/docs/api#recent-builds
 	// https://circleci.com/api/v1/recent-builds
 	RecentBuilds() ([]BuildSummary, error)
 
+	// RecentBuildsContext is like RecentBuilds but uses ctx for the underlying request.
+	RecentBuildsContext(ctx context.Context) ([]BuildSummary, error)
+
 	// Provides build summary for each of the last 30 builds for a single git repo.
 	//
 	// https://circleci.com/docs/api#recent-builds-project
 	// https://circleci.com/api/v1/project/{username}/{project}
+	//
+	// Deprecated: Use BuildsForProject, which supports every VCS type.
 	RecentBuildsForProject(username, project string) ([]BuildSummary, error)
 
+	// RecentBuildsForProjectContext is like RecentBuildsForProject but uses ctx for the underlying request.
+	//
+	// Deprecated: Use BuildsForProject, which supports every VCS type.
+	RecentBuildsForProjectContext(ctx context.Context, username, project string) ([]BuildSummary, error)
+
 	// Provides build summary for each of the last 30 builds for a single branch of a
 	// github branch.
 	//
 	// https://circleci.com/docs/api#recent-builds-project
 	// https://circleci.com/api/v1/project/{username}/{project}
+	//
+	// Deprecated: Use BuildsForBranch, which supports every VCS type.
 	RecentBuildsForProjectBranch(username, project, branch string, opts RecentBuildsOptions) ([]BuildSummary, error)
 
+	// RecentBuildsForProjectBranchContext is like RecentBuildsForProjectBranch but uses ctx for the underlying request.
+	//
+	// Deprecated: Use BuildsForBranch, which supports every VCS type.
+	RecentBuildsForProjectBranchContext(ctx context.Context, username, project, branch string, opts RecentBuildsOptions) ([]BuildSummary, error)
+
 	// Provides a detailed build summary for the given build for the project.
 	//
 	// https://circleci.com/docs/api#build
 	// https://circleci.com/api/v1/project/{username}/{project}/{num}
+	//
+	// Deprecated: Use GetBuild, which supports every VCS type.
 	BuildSummary(username, project string, num int) (DetailedBuildSummary, error)
 
+	// BuildSummaryContext is like BuildSummary but uses ctx for the underlying request.
+	//
+	// Deprecated: Use GetBuild, which supports every VCS type.
+	BuildSummaryContext(ctx context.Context, username, project string, num int) (DetailedBuildSummary, error)
+
 	// List the artifacts produced by the given build.
 	//
 	// https://circleci.com/docs/api#build-artifacts
 	// https://circleci.com/api/v1/project/{username}/{project}/{num}/artifacts
+	//
+	// Deprecated: Use ListArtifacts, which supports every VCS type.
 	Artifacts(username, project string, num int) ([]Artifact, error)
 
+	// ArtifactsContext is like Artifacts but uses ctx for the underlying request.
+	//
+	// Deprecated: Use ListArtifacts, which supports every VCS type.
+	ArtifactsContext(ctx context.Context, username, project string, num int) ([]Artifact, error)
+
 	// Retries the build and returns a summary of the new build.
 	//
 	// https://circleci.com/docs/api#retry-build
 	// https://circleci.com/api/v1/project/{username}/{project}/{num}/retry
+	//
+	// Deprecated: Use RetryBuild, which supports every VCS type.
 	Retry(username, project string, num int) (Build, error)
 
+	// RetryContext is like Retry but uses ctx for the underlying request.
+	//
+	// Deprecated: Use RetryBuild, which supports every VCS type.
+	RetryContext(ctx context.Context, username, project string, num int) (Build, error)
+
 	// Cancels the build and returns a summary of the build.
 	//
 	// https://circleci.com/docs/api#cancel-build
 	// https://circleci.com/api/v1/project/{username}/{project}/{num}/cancel
+	//
+	// Deprecated: Use CancelBuild, which supports every VCS type.
 	Cancel(username, project string, num int) (Build, error)
 
+	// CancelContext is like Cancel but uses ctx for the underlying request.
+	//
+	// Deprecated: Use CancelBuild, which supports every VCS type.
+	CancelContext(ctx context.Context, username, project string, num int) (Build, error)
+
 	// Triggers a new build and returns a summary of the build.
 	//
 	// https://circleci.com/docs/api#new-build
 	// https://circleci.com/api/v1/project/{username}/{project}/tree/{branch}
+	//
+	// Deprecated: Use TriggerBuild, which supports every VCS type.
 	Build(username, project, branch string) (Build, error)
 
+	// BuildContext is like Build but uses ctx for the underlying request.
+	//
+	// Deprecated: Use TriggerBuild, which supports every VCS type.
+	BuildContext(ctx context.Context, username, project, branch string) (Build, error)
+
 	// Clears the cache for a project
 	//
 	// https://circleci.com/docs/api#clear-cache
 	// https://circleci.com/api/v1/project/{username}/{project}/build-cache
+	//
+	// Deprecated: Use ClearProjectCache, which supports every VCS type.
 	ClearCache(username, project string) (ClearCacheResponse, error)
+
+	// ClearCacheContext is like ClearCache but uses ctx for the underlying request.
+	//
+	// Deprecated: Use ClearProjectCache, which supports every VCS type.
+	ClearCacheContext(ctx context.Context, username, project string) (ClearCacheResponse, error)
+
+	// The following methods address projects by ProjectSlug, and so support
+	// every VCS type rather than just GitHub. They replace the GitHub-only
+	// methods above.
+
+	// Provides build summary for each of the recent builds of the project.
+	//
+	// https://circleci.com/docs/api#recent-builds-project
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}
+	BuildsForProject(ctx context.Context, p ProjectSlug, opts RecentBuildsOptions) ([]BuildSummary, error)
+
+	// Provides build summary for each of the recent builds of a single branch of
+	// the project. The branch name is escaped as a single path segment, so that
+	// e.g. `feature/foo` is requested as `tree/feature%2Ffoo`.
+	//
+	// https://circleci.com/docs/api#recent-builds-project
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/tree/{branch}
+	BuildsForBranch(ctx context.Context, p ProjectSlug, branch string, opts RecentBuildsOptions) ([]BuildSummary, error)
+
+	// Provides a detailed build summary for the given build of the project.
+	//
+	// https://circleci.com/docs/api#build
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/{num}
+	GetBuild(ctx context.Context, p ProjectSlug, num int) (DetailedBuildSummary, error)
+
+	// List the artifacts produced by the given build.
+	//
+	// https://circleci.com/docs/api#build-artifacts
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/{num}/artifacts
+	ListArtifacts(ctx context.Context, p ProjectSlug, num int) ([]Artifact, error)
+
+	// Provides the results of the tests of the given build, as parsed from its
+	// JUnit reports.
+	//
+	// https://circleci.com/docs/api#test-metadata
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/{num}/tests
+	TestMetadata(ctx context.Context, p ProjectSlug, num int) ([]TestResult, error)
+
+	// Retries the build and returns a summary of the new build.
+	//
+	// https://circleci.com/docs/api#retry-build
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/{num}/retry
+	RetryBuild(ctx context.Context, p ProjectSlug, num int) (Build, error)
+
+	// Cancels the build and returns a summary of the build.
+	//
+	// https://circleci.com/docs/api#cancel-build
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/{num}/cancel
+	CancelBuild(ctx context.Context, p ProjectSlug, num int) (Build, error)
+
+	// Triggers a new build of the project and returns a summary of the build.
+	// Without a branch or tag in `opts`, the default branch is built.
+	//
+	// https://circleci.com/docs/api#new-build
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/tree/{branch}
+	TriggerBuild(ctx context.Context, p ProjectSlug, opts TriggerBuildOptions) (Build, error)
+
+	// Clears the cache for the project.
+	//
+	// https://circleci.com/docs/api#clear-cache
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/build-cache
+	ClearProjectCache(ctx context.Context, p ProjectSlug) (ClearCacheResponse, error)
+
+	// Follows the project, so that it is listed by Projects.
+	//
+	// https://circleci.com/docs/api#follow-project
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/follow
+	FollowProject(ctx context.Context, p ProjectSlug) (FollowResponse, error)
+
+	// Unfollows the project.
+	//
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/unfollow
+	UnfollowProject(ctx context.Context, p ProjectSlug) (FollowResponse, error)
+
+	// Enables building the project, adding the deploy key and webhooks it
+	// needs to its repository.
+	//
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/enable
+	EnableProject(ctx context.Context, p ProjectSlug) (Project, error)
+
+	// Lists the environment variables of the project, with masked values.
+	//
+	// https://circleci.com/docs/api#list-environment-variables
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/envvar
+	ListEnvVars(ctx context.Context, p ProjectSlug) ([]EnvVar, error)
+
+	// Gets a single environment variable of the project, with a masked value.
+	//
+	// https://circleci.com/docs/api#get-single-environment-variable
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/envvar/{name}
+	GetEnvVar(ctx context.Context, p ProjectSlug, name string) (EnvVar, error)
+
+	// Creates or replaces an environment variable of the project, and returns
+	// it with a masked value.
+	//
+	// https://circleci.com/docs/api#add-environment-variable
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/envvar
+	SetEnvVar(ctx context.Context, p ProjectSlug, name, value string) (EnvVar, error)
+
+	// Deletes an environment variable of the project.
+	//
+	// https://circleci.com/docs/api#delete-environment-variable
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/envvar/{name}
+	DeleteEnvVar(ctx context.Context, p ProjectSlug, name string) error
+
+	// Lists the checkout keys of the project.
+	//
+	// https://circleci.com/docs/api#list-checkout-keys
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/checkout-key
+	ListCheckoutKeys(ctx context.Context, p ProjectSlug) ([]CheckoutKey, error)
+
+	// Creates a checkout key of the given type for the project.
+	//
+	// https://circleci.com/docs/api#new-checkout-key
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/checkout-key
+	CreateCheckoutKey(ctx context.Context, p ProjectSlug, t CheckoutKeyType) (CheckoutKey, error)
+
+	// Gets the checkout key of the project with the given fingerprint.
+	//
+	// https://circleci.com/docs/api#get-checkout-key
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/checkout-key/{fingerprint}
+	GetCheckoutKey(ctx context.Context, p ProjectSlug, fingerprint string) (CheckoutKey, error)
+
+	// Deletes the checkout key of the project with the given fingerprint.
+	//
+	// https://circleci.com/docs/api#delete-checkout-key
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/checkout-key/{fingerprint}
+	DeleteCheckoutKey(ctx context.Context, p ProjectSlug, fingerprint string) error
+
+	// Adds an SSH key builds of the project use to connect to `hostname`, or
+	// to any host if it is empty. The project's keys are listed in
+	// Project.SSHKeys.
+	//
+	// https://circleci.com/docs/api#ssh-keys
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/ssh-key
+	AddSSHKey(ctx context.Context, p ProjectSlug, hostname, privateKey string) error
+
+	// Removes the SSH key of the project with the given hostname and
+	// fingerprint.
+	//
+	// https://circleci.com/docs/api#ssh-keys
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/ssh-key
+	RemoveSSHKey(ctx context.Context, p ProjectSlug, hostname, fingerprint string) error
+
+	// Fetches the output of an action of the given build, as reported in
+	// DetailedBuildSummary.Steps. CircleCI truncates long output, as indicated
+	// by Action.Truncated.
+	//
+	// https://circleci.com/api/v1.1/project/{vcs-type}/{org}/{repo}/{num}/output/{step}/{index}
+	ActionOutput(ctx context.Context, p ProjectSlug, num int, a Action) ([]OutputMessage, error)
+
+	// Downloads an artifact listed by ListArtifacts or Artifacts. The caller
+	// must close the returned reader.
+	DownloadArtifact(ctx context.Context, a Artifact) (io.ReadCloser, error)
+
+	// Downloads an artifact from byte `offset` onwards, e.g. to resume an
+	// interrupted download. Servers that do not support ranges send the whole
+	// artifact, as reported by ArtifactRange.Offset.
+	DownloadArtifactRange(ctx context.Context, a Artifact, offset int64) (ArtifactRange, error)
 }
 
 type client struct {
-	token string
-	http  *http.Client
+	token      string
+	http       *http.Client
+	baseURL    string
+	apiVersion string
+	userAgent  string
+	header     http.Header
+
+	maxResponseSize int64
+	retry           RetryPolicy
+	limiter         *limiter
+	middleware      []Middleware
+	roundTrip       Handler
+	logger          *slog.Logger
+	metrics         *Metrics
+	decodeMode      DecodeMode
+	report          func(Diagnostic)
+}
+
+// New returns a Client for the given `token`, configured by `opts`.
+func New(token string, opts ...Option) CircleCI {
+	c := &client{
+		token:      token,
+		http:       http.DefaultClient,
+		baseURL:    defaultBaseURL,
+		apiVersion: defaultAPIVersion,
+		header:     make(http.Header),
+
+		maxResponseSize: defaultMaxResponseSize,
+		retry:           DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.roundTrip = c.handler()
+	return c
 }
 
-// New returns a Client for the given `token`.
-func New(token string) CircleCI {
-	return &client{token, http.DefaultClient}
+// String describes the client without revealing its token.
+func (c *client) String() string {
+	return fmt.Sprintf("circle.client{baseURL: %q, apiVersion: %q, token: %s}", c.baseURL, c.apiVersion, redacted)
 }
 
-func (c *client) endpoint(endpoint string) string {
-	return fmt.Sprintf("https://circleci.com/api/v1%s?circle-token=%s", endpoint, c.token)
+// GoString describes the client without revealing its token.
+func (c *client) GoString() string {
+	return c.String()
 }
 
 // Information about the authenticated user.
 type Me struct {
-	Admin               bool        `json:"admin"`
-	Emails              []string    `json:"all_emails"`
-	AvatarURL           string      `json:"avatar_url"`
-	BasicEmailPrefs     string      `json:"basic_email_prefs"`
-	Containers          int         `json:"containers"`
-	CreatedAt           string      `json:"created_at"`
-	DaysLeftInTrial     int         `json:"days_left_in_trial"`
-	DevAdmin            bool        `json:"dev_admin"`
-	GithubID            int         `json:"github_id"`
-	GithubOauthScopes   []string    `json:"github_oauth_scopes"`
-	GravatarID          interface{} `json:"gravatar_id"`
-	HerokuAPIKey        interface{} `json:"heroku_api_key"`
-	LastViewedChangelog string      `json:"last_viewed_changelog"`
-	Login               string      `json:"login"`
-	Name                string      `json:"name"`
-	Parallelism         int         `json:"parallelism"`
-	Plan                interface{} `json:"plan"`
-	Projects            map[string]struct {
-		Emails      string `json:"emails"`
-		OnDashboard bool   `json:"on_dashboard"`
-	} `json:"projects"`
-	SelectedEmail string `json:"selected_email"`
-	SignInCount   int    `json:"sign_in_count"`
-	TrialEnd      string `json:"trial_end"`
+	Admin               bool                          `json:"admin"`
+	Emails              []string                      `json:"all_emails"`
+	AvatarURL           string                        `json:"avatar_url"`
+	BasicEmailPrefs     string                        `json:"basic_email_prefs"`
+	Containers          int                           `json:"containers"`
+	CreatedAt           Time                          `json:"created_at"`
+	DaysLeftInTrial     int                           `json:"days_left_in_trial"`
+	DevAdmin            bool                          `json:"dev_admin"`
+	GithubID            int                           `json:"github_id"`
+	GithubOauthScopes   []string                      `json:"github_oauth_scopes"`
+	GravatarID          *string                       `json:"gravatar_id"`
+	HerokuAPIKey        *string                       `json:"heroku_api_key"`
+	LastViewedChangelog string                        `json:"last_viewed_changelog"`
+	Login               string                        `json:"login"`
+	Name                string                        `json:"name"`
+	Parallelism         int                           `json:"parallelism"`
+	Plan                *string                       `json:"plan"`
+	Projects            map[string]ProjectPreferences `json:"projects"`
+	SelectedEmail       string                        `json:"selected_email"`
+	SignInCount         int                           `json:"sign_in_count"`
+	TrialEnd            Time                          `json:"trial_end"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
 }
 
-func (c *client) Me() (Me, error) {
-	url := c.endpoint("/me")
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (m *Me) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, m, &m.Unknown, &m.members)
+}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return Me{}, err
-	}
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (m Me) MarshalJSON() ([]byte, error) {
+	return marshalModel(&m, m.Unknown, m.members)
+}
 
-	request.Header.Set("Accept", "application/json")
+// Preferences of the authenticated user for a followed project.
+type ProjectPreferences struct {
+	Emails      string `json:"emails"`
+	OnDashboard bool   `json:"on_dashboard"`
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return Me{}, err
-	}
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (p *ProjectPreferences) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, p, &p.Unknown, &p.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (p ProjectPreferences) MarshalJSON() ([]byte, error) {
+	return marshalModel(&p, p.Unknown, p.members)
+}
+
+func (c *client) Me() (Me, error) {
+	return c.MeContext(context.Background())
+}
 
+func (c *client) MeContext(ctx context.Context) (Me, error) {
 	var m Me
-	err = json.NewDecoder(response.Body).Decode(&m)
+	err := c.do(ctx, call{
+		op:     "Me",
+		method: "GET",
+		path:   "/me",
+	}, &m)
 	if err != nil {
 		return Me{}, err
 	}
@@ -142,72 +442,181 @@ func (c *client) Me() (Me, error) {
 
 // Information about a project.
 type Project struct {
-	AWS struct {
-		KeyPair interface{} `json:"keypair"`
-	} `json:"aws"`
-	Branches map[string]struct {
-		AddedAt     string `json:"added_at"`
-		BuildNum    int    `json:"build_num"`
-		Outcome     string `json:"outcome"`
-		PushedAt    string `json:"pushed_at"`
-		Status      string `json:"status"`
-		VcsRevision string `json:"vcs_revision"`
-	} `json:"branches"`
-	CampfireNotifyPrefs interface{}     `json:"campfire_notify_prefs"`
-	CampfireRoom        interface{}     `json:"campfire_room"`
-	CampfireSubdomain   interface{}     `json:"campfire_subdomain"`
-	CampfireToken       interface{}     `json:"campfire_token"`
-	Compile             string          `json:"compile"`
-	DefaultBranch       string          `json:"default_branch"`
-	Dependencies        string          `json:"dependencies"`
-	Extra               string          `json:"extra"`
-	FeatureFlags        map[string]bool `json:"feature_flags"`
-	FlowdockAPIToken    interface{}     `json:"flowdock_api_token"`
-	Followed            bool            `json:"followed"`
-	HasUsableKey        bool            `json:"has_usable_key"`
-	HerokuDeployUser    interface{}     `json:"heroku_deploy_user"`
-	HipChatAPIToken     interface{}     `json:"hipchat_api_token"`
-	HipChatNotify       interface{}     `json:"hipchat_notify"`
-	HipChatNotifyPrefs  interface{}     `json:"hipchat_notify_prefs"`
-	HipChatRoom         interface{}     `json:"hipchat_room"`
-	IRCChannel          interface{}     `json:"irc_channel"`
-	IRCKeyword          interface{}     `json:"irc_keyword"`
-	IRCNotifyPrefs      interface{}     `json:"irc_notify_prefs"`
-	IRCPassword         interface{}     `json:"irc_password"`
-	IRCServer           interface{}     `json:"irc_server"`
-	IRCUsername         interface{}     `json:"irc_username"`
-	Parallel            int             `json:"parallel"`
-	Reponame            string          `json:"reponame"`
-	Scopes              []string        `json:"scopes"`
-	Setup               string          `json:"setup"`
-	SlackAPIToken       interface{}     `json:"slack_api_token"`
-	SlackChannel        interface{}     `json:"slack_channel"`
-	SlackNotifyPrefs    interface{}     `json:"slack_notify_prefs"`
-	SlackSubdomain      interface{}     `json:"slack_subdomain"`
-	SlackWebhookURL     string          `json:"slack_webhook_url"`
-	SSHKeys             []interface{}   `json:"ssh_keys"`
-	Test                string          `json:"test"`
-	Username            string          `json:"username"`
-	VCSURL              string          `json:"vcs_url"`
+	AWS                 AWSConfig               `json:"aws"`
+	Branches            map[string]BranchStatus `json:"branches"`
+	CampfireNotifyPrefs *string                 `json:"campfire_notify_prefs"`
+	CampfireRoom        *string                 `json:"campfire_room"`
+	CampfireSubdomain   *string                 `json:"campfire_subdomain"`
+	CampfireToken       *string                 `json:"campfire_token"`
+	Compile             string                  `json:"compile"`
+	DefaultBranch       string                  `json:"default_branch"`
+	Dependencies        string                  `json:"dependencies"`
+	Extra               string                  `json:"extra"`
+	FeatureFlags        map[string]bool         `json:"feature_flags"`
+	FlowdockAPIToken    *string                 `json:"flowdock_api_token"`
+	Followed            bool                    `json:"followed"`
+	HasUsableKey        bool                    `json:"has_usable_key"`
+	HerokuDeployUser    *string                 `json:"heroku_deploy_user"`
+	HipChatAPIToken     *string                 `json:"hipchat_api_token"`
+	HipChatNotify       *bool                   `json:"hipchat_notify"`
+	HipChatNotifyPrefs  *string                 `json:"hipchat_notify_prefs"`
+	HipChatRoom         *string                 `json:"hipchat_room"`
+	IRCChannel          *string                 `json:"irc_channel"`
+	IRCKeyword          *string                 `json:"irc_keyword"`
+	IRCNotifyPrefs      *string                 `json:"irc_notify_prefs"`
+	IRCPassword         *string                 `json:"irc_password"`
+	IRCServer           *string                 `json:"irc_server"`
+	IRCUsername         *string                 `json:"irc_username"`
+	Parallel            int                     `json:"parallel"`
+	Reponame            string                  `json:"reponame"`
+	Scopes              []string                `json:"scopes"`
+	Setup               string                  `json:"setup"`
+	SlackAPIToken       *string                 `json:"slack_api_token"`
+	SlackChannel        *string                 `json:"slack_channel"`
+	SlackNotifyPrefs    *string                 `json:"slack_notify_prefs"`
+	SlackSubdomain      *string                 `json:"slack_subdomain"`
+	SlackWebhookURL     string                  `json:"slack_webhook_url"`
+	SSHKeys             []SSHKey                `json:"ssh_keys"`
+	Test                string                  `json:"test"`
+	Username            string                  `json:"username"`
+	VCSURL              string                  `json:"vcs_url"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
 }
 
-func (c *client) Projects() ([]Project, error) {
-	url := c.endpoint("/projects")
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (p *Project) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, p, &p.Unknown, &p.members)
+}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return make([]Project, 0), err
-	}
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (p Project) MarshalJSON() ([]byte, error) {
+	return marshalModel(&p, p.Unknown, p.members)
+}
 
-	request.Header.Set("Accept", "application/json")
+// Status of the latest build of a branch of a project.
+type BranchStatus struct {
+	AddedAt     Time    `json:"added_at"`
+	BuildNum    int     `json:"build_num"`
+	Outcome     Outcome `json:"outcome"`
+	PushedAt    Time    `json:"pushed_at"`
+	Status      Status  `json:"status"`
+	VcsRevision string  `json:"vcs_revision"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return make([]Project, 0), err
-	}
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (b *BranchStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, b, &b.Unknown, &b.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (b BranchStatus) MarshalJSON() ([]byte, error) {
+	return marshalModel(&b, b.Unknown, b.members)
+}
+
+// IsTerminal reports whether the branch's latest build has stopped and will
+// not change status again.
+func (b BranchStatus) IsTerminal() bool {
+	return b.Status.IsTerminal()
+}
+
+// IsGreen reports whether the branch's latest build passed.
+func (b BranchStatus) IsGreen() bool {
+	return b.Status.IsGreen()
+}
+
+// IsRunning reports whether the branch's latest build is running.
+func (b BranchStatus) IsRunning() bool {
+	return b.Status.IsRunning()
+}
+
+// AWS configuration of a project.
+type AWSConfig struct {
+	KeyPair *AWSKeyPair `json:"keypair"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (a *AWSConfig) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, a, &a.Unknown, &a.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (a AWSConfig) MarshalJSON() ([]byte, error) {
+	return marshalModel(&a, a.Unknown, a.members)
+}
+
+// AWS credentials configured for a project.
+type AWSKeyPair struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (k *AWSKeyPair) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, k, &k.Unknown, &k.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (k AWSKeyPair) MarshalJSON() ([]byte, error) {
+	return marshalModel(&k, k.Unknown, k.members)
+}
+
+// SSH key configured for a project.
+type SSHKey struct {
+	Fingerprint string `json:"fingerprint"`
+	Hostname    string `json:"hostname"`
+	PublicKey   string `json:"public_key"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (k *SSHKey) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, k, &k.Unknown, &k.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (k SSHKey) MarshalJSON() ([]byte, error) {
+	return marshalModel(&k, k.Unknown, k.members)
+}
+
+func (c *client) Projects() ([]Project, error) {
+	return c.ProjectsContext(context.Background())
+}
 
+func (c *client) ProjectsContext(ctx context.Context) ([]Project, error) {
 	var p []Project
-	err = json.NewDecoder(response.Body).Decode(&p)
+	err := c.do(ctx, call{
+		op:     "Projects",
+		method: "GET",
+		path:   "/projects",
+	}, &p)
 	if err != nil {
 		return make([]Project, 0), err
 	}
@@ -216,110 +625,286 @@ func (c *client) Projects() ([]Project, error) {
 }
 
 // Summary of a build.
+//
+// BuildParameters holds the parameters the build was triggered with as raw
+// JSON, since CircleCI echoes back whatever values were given, which need not
+// be strings.
 type BuildSummary struct {
-	CommitDetails []struct {
-		AuthorDate     string `json:"author_date"`
-		AuthorEmail    string `json:"author_email"`
-		AuthorLogin    string `json:"author_login"`
-		AuthorName     string `json:"author_name"`
-		Body           string `json:"body"`
-		Branch         string `json:"branch"`
-		Commit         string `json:"commit"`
-		CommitURL      string `json:"commit_url"`
-		CommitterDate  string `json:"committer_date"`
-		CommitterEmail string `json:"committer_email"`
-		CommitterLogin string `json:"committer_login"`
-		CommitterName  string `json:"committer_name"`
-		Subject        string `json:"subject"`
-	} `json:"all_commit_details"`
-	AuthorDate      string      `json:"author_date"`
-	AuthorEmail     string      `json:"author_email"`
-	AuthorName      string      `json:"author_name"`
-	Body            string      `json:"body"`
-	Branch          string      `json:"branch"`
-	BuildNum        int         `json:"build_num"`
-	BuildParameters interface{} `json:"build_parameters"`
-	BuildTimeMillis int         `json:"build_time_millis"`
-	BuildURL        string      `json:"build_url"`
-	Canceled        bool        `json:"canceled"`
-	Canceler        interface{} `json:"canceler"`
-	CircleYml       struct {
-		String string `json:"string"`
-	} `json:"circle_yml"`
-	CommitterDate      string        `json:"committer_date"`
-	CommitterEmail     string        `json:"committer_email"`
-	CommitterName      string        `json:"committer_name"`
-	Compare            string        `json:"compare"`
-	DontBuild          interface{}   `json:"dont_build"`
-	Failed             interface{}   `json:"failed"`
-	FeatureFlags       struct{}      `json:"feature_flags"`
-	HasArtifacts       bool          `json:"has_artifacts"`
-	InfrastructureFail bool          `json:"infrastructure_fail"`
-	IsFirstGreenBuild  bool          `json:"is_first_green_build"`
-	JobName            interface{}   `json:"job_name"`
-	Lifecycle          string        `json:"lifecycle"`
-	Messages           []interface{} `json:"messages"`
-	Node               []struct {
-		ImageID      string      `json:"image_id"`
-		Port         int         `json:"port"`
-		PublicIPAddr string      `json:"public_ip_addr"`
-		SSHEnabled   interface{} `json:"ssh_enabled"`
-		Username     string      `json:"username"`
-	} `json:"node"`
-	Oss      bool   `json:"oss"`
-	Outcome  string `json:"outcome"`
-	Parallel int    `json:"parallel"`
-	Previous struct {
-		BuildNum        int    `json:"build_num"`
-		BuildTimeMillis int    `json:"build_time_millis"`
-		Status          string `json:"status"`
-	} `json:"previous"`
-	PreviousSuccessfulBuild struct {
-		BuildNum        int    `json:"build_num"`
-		BuildTimeMillis int    `json:"build_time_millis"`
-		Status          string `json:"status"`
-	} `json:"previous_successful_build"`
-	QueuedAt      string        `json:"queued_at"`
-	Reponame      string        `json:"reponame"`
-	Retries       interface{}   `json:"retries"`
-	RetryOf       int           `json:"retry_of"`
-	SSHEnabled    interface{}   `json:"ssh_enabled"`
-	SSHUsers      []interface{} `json:"ssh_users"`
-	StartTime     string        `json:"start_time"`
-	Status        string        `json:"status"`
-	StopTime      string        `json:"stop_time"`
-	Subject       string        `json:"subject"`
-	Timedout      bool          `json:"timedout"`
-	UsageQueuedAt string        `json:"usage_queued_at"`
-	User          struct {
-		Email  string `json:"email"`
-		IsUser bool   `json:"is_user"`
-		Login  string `json:"login"`
-		Name   string `json:"name"`
-	} `json:"user"`
-	Username    string `json:"username"`
-	VCSRevision string `json:"vcs_revision"`
-	VCSURL      string `json:"vcs_url"`
-	Why         string `json:"why"`
+	CommitDetails           []CommitDetail             `json:"all_commit_details"`
+	AuthorDate              Time                       `json:"author_date"`
+	AuthorEmail             string                     `json:"author_email"`
+	AuthorName              string                     `json:"author_name"`
+	Body                    string                     `json:"body"`
+	Branch                  string                     `json:"branch"`
+	BuildNum                int                        `json:"build_num"`
+	BuildParameters         map[string]json.RawMessage `json:"build_parameters"`
+	BuildTimeMillis         int                        `json:"build_time_millis"`
+	BuildURL                string                     `json:"build_url"`
+	Canceled                bool                       `json:"canceled"`
+	Canceler                *User                      `json:"canceler"`
+	CircleYml               CircleYml                  `json:"circle_yml"`
+	CommitterDate           Time                       `json:"committer_date"`
+	CommitterEmail          string                     `json:"committer_email"`
+	CommitterName           string                     `json:"committer_name"`
+	Compare                 string                     `json:"compare"`
+	DontBuild               *string                    `json:"dont_build"`
+	Failed                  *bool                      `json:"failed"`
+	FeatureFlags            map[string]bool            `json:"feature_flags"`
+	HasArtifacts            bool                       `json:"has_artifacts"`
+	InfrastructureFail      bool                       `json:"infrastructure_fail"`
+	IsFirstGreenBuild       bool                       `json:"is_first_green_build"`
+	JobName                 *string                    `json:"job_name"`
+	Lifecycle               Lifecycle                  `json:"lifecycle"`
+	Messages                []Message                  `json:"messages"`
+	Node                    []BuildNode                `json:"node"`
+	Oss                     bool                       `json:"oss"`
+	Outcome                 Outcome                    `json:"outcome"`
+	Parallel                int                        `json:"parallel"`
+	Previous                PreviousBuild              `json:"previous"`
+	PreviousSuccessfulBuild PreviousBuild              `json:"previous_successful_build"`
+	QueuedAt                Time                       `json:"queued_at"`
+	Reponame                string                     `json:"reponame"`
+	Retries                 []int                      `json:"retries"`
+	RetryOf                 int                        `json:"retry_of"`
+	SSHEnabled              *bool                      `json:"ssh_enabled"`
+	SSHUsers                []SSHUser                  `json:"ssh_users"`
+	StartTime               Time                       `json:"start_time"`
+	Status                  Status                     `json:"status"`
+	StopTime                Time                       `json:"stop_time"`
+	Subject                 string                     `json:"subject"`
+	Timedout                bool                       `json:"timedout"`
+	UsageQueuedAt           Time                       `json:"usage_queued_at"`
+	User                    User                       `json:"user"`
+	Username                string                     `json:"username"`
+	VCSRevision             string                     `json:"vcs_revision"`
+	VCSURL                  string                     `json:"vcs_url"`
+	Why                     string                     `json:"why"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
 }
 
-func (c *client) RecentBuilds() ([]BuildSummary, error) {
-	url := c.endpoint("/recent-builds")
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (b *BuildSummary) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, b, &b.Unknown, &b.members)
+}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return make([]BuildSummary, 0), err
-	}
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (b BuildSummary) MarshalJSON() ([]byte, error) {
+	return marshalModel(&b, b.Unknown, b.members)
+}
 
-	request.Header.Set("Accept", "application/json")
+// Details of a commit included in a build.
+type CommitDetail struct {
+	AuthorDate     Time   `json:"author_date"`
+	AuthorEmail    string `json:"author_email"`
+	AuthorLogin    string `json:"author_login"`
+	AuthorName     string `json:"author_name"`
+	Body           string `json:"body"`
+	Branch         string `json:"branch"`
+	Commit         string `json:"commit"`
+	CommitURL      string `json:"commit_url"`
+	CommitterDate  Time   `json:"committer_date"`
+	CommitterEmail string `json:"committer_email"`
+	CommitterLogin string `json:"committer_login"`
+	CommitterName  string `json:"committer_name"`
+	Subject        string `json:"subject"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return make([]BuildSummary, 0), err
-	}
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (d *CommitDetail) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, d, &d.Unknown, &d.members)
+}
 
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (d CommitDetail) MarshalJSON() ([]byte, error) {
+	return marshalModel(&d, d.Unknown, d.members)
+}
+
+// Configuration a build ran with.
+type CircleYml struct {
+	String string `json:"string"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (y *CircleYml) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, y, &y.Unknown, &y.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (y CircleYml) MarshalJSON() ([]byte, error) {
+	return marshalModel(&y, y.Unknown, y.members)
+}
+
+// Container a build ran on.
+type BuildNode struct {
+	ImageID      string `json:"image_id"`
+	Port         int    `json:"port"`
+	PublicIPAddr string `json:"public_ip_addr"`
+	SSHEnabled   *bool  `json:"ssh_enabled"`
+	Username     string `json:"username"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (n *BuildNode) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, n, &n.Unknown, &n.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (n BuildNode) MarshalJSON() ([]byte, error) {
+	return marshalModel(&n, n.Unknown, n.members)
+}
+
+// Summary of an earlier build of the same project.
+type PreviousBuild struct {
+	BuildNum        int    `json:"build_num"`
+	BuildTimeMillis int    `json:"build_time_millis"`
+	Status          Status `json:"status"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (b *PreviousBuild) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, b, &b.Unknown, &b.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (b PreviousBuild) MarshalJSON() ([]byte, error) {
+	return marshalModel(&b, b.Unknown, b.members)
+}
+
+// BuildTime returns how long the build ran for.
+func (b PreviousBuild) BuildTime() time.Duration {
+	return millis(b.BuildTimeMillis)
+}
+
+// A CircleCI user, e.g. the one who canceled a build.
+type User struct {
+	Email  string `json:"email"`
+	IsUser bool   `json:"is_user"`
+	Login  string `json:"login"`
+	Name   string `json:"name"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (u *User) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, u, &u.Unknown, &u.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (u User) MarshalJSON() ([]byte, error) {
+	return marshalModel(&u, u.Unknown, u.members)
+}
+
+// Message attached to a build, e.g. a configuration warning.
+type Message struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Type    string `json:"type"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, m, &m.Unknown, &m.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (m Message) MarshalJSON() ([]byte, error) {
+	return marshalModel(&m, m.Unknown, m.members)
+}
+
+// User with SSH access to a build.
+type SSHUser struct {
+	GithubID int    `json:"github_id"`
+	Login    string `json:"login"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (u *SSHUser) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, u, &u.Unknown, &u.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (u SSHUser) MarshalJSON() ([]byte, error) {
+	return marshalModel(&u, u.Unknown, u.members)
+}
+
+// BuildTime returns how long the build ran for.
+func (b BuildSummary) BuildTime() time.Duration {
+	return millis(b.BuildTimeMillis)
+}
+
+// IsTerminal reports whether the build has stopped and will not change status
+// again.
+func (b BuildSummary) IsTerminal() bool {
+	return b.Status.IsTerminal()
+}
+
+// IsGreen reports whether the build passed.
+func (b BuildSummary) IsGreen() bool {
+	return b.Status.IsGreen()
+}
+
+// IsRunning reports whether the build is running.
+func (b BuildSummary) IsRunning() bool {
+	return b.Status.IsRunning()
+}
+
+func (c *client) RecentBuilds() ([]BuildSummary, error) {
+	return c.RecentBuildsContext(context.Background())
+}
+
+func (c *client) RecentBuildsContext(ctx context.Context) ([]BuildSummary, error) {
 	var b []BuildSummary
-	err = json.NewDecoder(response.Body).Decode(&b)
+	err := c.do(ctx, call{
+		op:     "RecentBuilds",
+		method: "GET",
+		path:   "/recent-builds",
+	}, &b)
 	if err != nil {
 		return make([]BuildSummary, 0), err
 	}
@@ -328,22 +913,26 @@ func (c *client) RecentBuilds() ([]BuildSummary, error) {
 }
 
 func (c *client) RecentBuildsForProject(username, project string) ([]BuildSummary, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s", username, project))
-
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return make([]BuildSummary, 0), err
-	}
+	return c.RecentBuildsForProjectContext(context.Background(), username, project)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) RecentBuildsForProjectContext(ctx context.Context, username, project string) ([]BuildSummary, error) {
+	return c.projectBuilds(ctx, "RecentBuildsForProject", GitHubProject(username, project), legacyParams(username, project), RecentBuildsOptions{})
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return make([]BuildSummary, 0), err
-	}
+func (c *client) BuildsForProject(ctx context.Context, p ProjectSlug, opts RecentBuildsOptions) ([]BuildSummary, error) {
+	return c.projectBuilds(ctx, "BuildsForProject", p, p.params(), opts)
+}
 
+func (c *client) projectBuilds(ctx context.Context, op string, p ProjectSlug, params map[string]string, opts RecentBuildsOptions) ([]BuildSummary, error) {
 	var b []BuildSummary
-	err = json.NewDecoder(response.Body).Decode(&b)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "GET",
+		path:   p.path(),
+		query:  opts.query(),
+		params: params,
+	}, &b)
 	if err != nil {
 		return make([]BuildSummary, 0), err
 	}
@@ -351,38 +940,51 @@ func (c *client) RecentBuildsForProject(username, project string) ([]BuildSummar
 	return b, nil
 }
 
+// Options for listing recent builds. Unset options are left out of the query.
 type RecentBuildsOptions struct {
 	Limit  *int
 	Offset *int
 	Filter *string
 }
 
-func (c *client) RecentBuildsForProjectBranch(username, project, branch string, options RecentBuildsOptions) ([]BuildSummary, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/tree/%s", username, project, branch))
-	if options.Limit != nil {
-		url = fmt.Sprintf("%s&limit=%d", url, *options.Limit)
+// query returns the options as query parameters.
+func (o RecentBuildsOptions) query() url.Values {
+	query := make(url.Values)
+	if o.Limit != nil {
+		query.Set("limit", strconv.Itoa(*o.Limit))
 	}
-	if options.Offset != nil {
-		url = fmt.Sprintf("%s&offset=%d", url, *options.Offset)
+	if o.Offset != nil {
+		query.Set("offset", strconv.Itoa(*o.Offset))
 	}
-	if options.Filter != nil {
-		url = fmt.Sprintf("%s&filter=%s", url, *options.Filter)
+	if o.Filter != nil {
+		query.Set("filter", *o.Filter)
 	}
+	return query
+}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return make([]BuildSummary, 0), err
-	}
+func (c *client) RecentBuildsForProjectBranch(username, project, branch string, options RecentBuildsOptions) ([]BuildSummary, error) {
+	return c.RecentBuildsForProjectBranchContext(context.Background(), username, project, branch, options)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) RecentBuildsForProjectBranchContext(ctx context.Context, username, project, branch string, options RecentBuildsOptions) ([]BuildSummary, error) {
+	return c.branchBuilds(ctx, "RecentBuildsForProjectBranch", GitHubProject(username, project), legacyParams(username, project), branch, options)
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return make([]BuildSummary, 0), err
-	}
+func (c *client) BuildsForBranch(ctx context.Context, p ProjectSlug, branch string, opts RecentBuildsOptions) ([]BuildSummary, error) {
+	return c.branchBuilds(ctx, "BuildsForBranch", p, p.params(), branch, opts)
+}
+
+func (c *client) branchBuilds(ctx context.Context, op string, p ProjectSlug, params map[string]string, branch string, opts RecentBuildsOptions) ([]BuildSummary, error) {
+	params["branch"] = branch
 
 	var b []BuildSummary
-	err = json.NewDecoder(response.Body).Decode(&b)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "GET",
+		path:   p.path("tree", branch),
+		query:  opts.query(),
+		params: params,
+	}, &b)
 	if err != nil {
 		return make([]BuildSummary, 0), err
 	}
@@ -393,52 +995,117 @@ func (c *client) RecentBuildsForProjectBranch(username, project, branch string,
 // Detailed summary of a build.
 type DetailedBuildSummary struct {
 	BuildSummary
-	Owners          []string      `json:"owners"`
-	PullRequestUrls []interface{} `json:"pull_request_urls"`
-	Steps           []struct {
-		Actions []struct {
-			BashCommand        interface{}   `json:"bash_command"`
-			Canceled           interface{}   `json:"canceled"`
-			Command            string        `json:"command"`
-			Continue           interface{}   `json:"continue"`
-			EndTime            string        `json:"end_time"`
-			ExitCode           interface{}   `json:"exit_code"`
-			Failed             interface{}   `json:"failed"`
-			HasOutput          bool          `json:"has_output"`
-			Index              int           `json:"index"`
-			InfrastructureFail interface{}   `json:"infrastructure_fail"`
-			Messages           []interface{} `json:"messages"`
-			Name               string        `json:"name"`
-			Parallel           bool          `json:"parallel"`
-			RunTimeMillis      int           `json:"run_time_millis"`
-			StartTime          string        `json:"start_time"`
-			Status             string        `json:"status"`
-			Step               int           `json:"step"`
-			Timedout           interface{}   `json:"timedout"`
-			Truncated          bool          `json:"truncated"`
-			Type               string        `json:"type"`
-		} `json:"actions"`
-		Name string `json:"name"`
-	} `json:"steps"`
+	Owners          []string `json:"owners"`
+	PullRequestUrls []string `json:"pull_request_urls"`
+	Steps           []Step   `json:"steps"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (b *DetailedBuildSummary) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, b, &b.Unknown, &b.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (b DetailedBuildSummary) MarshalJSON() ([]byte, error) {
+	return marshalModel(&b, b.Unknown, b.members)
+}
+
+// Step of a build, run as one action per container.
+type Step struct {
+	Actions []Action `json:"actions"`
+	Name    string   `json:"name"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (s *Step) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, s, &s.Unknown, &s.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (s Step) MarshalJSON() ([]byte, error) {
+	return marshalModel(&s, s.Unknown, s.members)
+}
+
+// Action run by a step on one container.
+type Action struct {
+	BashCommand        *string  `json:"bash_command"`
+	Canceled           *bool    `json:"canceled"`
+	Command            string   `json:"command"`
+	Continue           *string  `json:"continue"`
+	EndTime            Time     `json:"end_time"`
+	ExitCode           *int     `json:"exit_code"`
+	Failed             *bool    `json:"failed"`
+	HasOutput          bool     `json:"has_output"`
+	OutputURL          string   `json:"output_url"`
+	Index              int      `json:"index"`
+	InfrastructureFail *bool    `json:"infrastructure_fail"`
+	Messages           []string `json:"messages"`
+	Name               string   `json:"name"`
+	Parallel           bool     `json:"parallel"`
+	RunTimeMillis      int      `json:"run_time_millis"`
+	StartTime          Time     `json:"start_time"`
+	Status             Status   `json:"status"`
+	Step               int      `json:"step"`
+	Timedout           *bool    `json:"timedout"`
+	Truncated          bool     `json:"truncated"`
+	Type               string   `json:"type"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (a *Action) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, a, &a.Unknown, &a.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (a Action) MarshalJSON() ([]byte, error) {
+	return marshalModel(&a, a.Unknown, a.members)
+}
+
+// RunTime returns how long the action ran for.
+func (a Action) RunTime() time.Duration {
+	return millis(a.RunTimeMillis)
 }
 
 func (c *client) BuildSummary(username, project string, num int) (DetailedBuildSummary, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/%d", username, project, num))
+	return c.BuildSummaryContext(context.Background(), username, project, num)
+}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return DetailedBuildSummary{}, err
-	}
+func (c *client) BuildSummaryContext(ctx context.Context, username, project string, num int) (DetailedBuildSummary, error) {
+	return c.buildSummary(ctx, "BuildSummary", GitHubProject(username, project), legacyParams(username, project), num)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) GetBuild(ctx context.Context, p ProjectSlug, num int) (DetailedBuildSummary, error) {
+	return c.buildSummary(ctx, "GetBuild", p, p.params(), num)
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return DetailedBuildSummary{}, err
-	}
+func (c *client) buildSummary(ctx context.Context, op string, p ProjectSlug, params map[string]string, num int) (DetailedBuildSummary, error) {
+	params["num"] = strconv.Itoa(num)
 
 	var b DetailedBuildSummary
-	err = json.NewDecoder(response.Body).Decode(&b)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "GET",
+		path:   p.path(strconv.Itoa(num)),
+		params: params,
+	}, &b)
 	if err != nil {
 		return DetailedBuildSummary{}, err
 	}
@@ -452,25 +1119,46 @@ type Artifact struct {
 	Path       string `json:"path"`
 	PrettyPath string `json:"pretty_path"`
 	URL        string `json:"url"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (a *Artifact) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, a, &a.Unknown, &a.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (a Artifact) MarshalJSON() ([]byte, error) {
+	return marshalModel(&a, a.Unknown, a.members)
 }
 
 func (c *client) Artifacts(username, project string, num int) ([]Artifact, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/%d/artifacts", username, project, num))
+	return c.ArtifactsContext(context.Background(), username, project, num)
+}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return make([]Artifact, 0), err
-	}
+func (c *client) ArtifactsContext(ctx context.Context, username, project string, num int) ([]Artifact, error) {
+	return c.artifacts(ctx, "Artifacts", GitHubProject(username, project), legacyParams(username, project), num)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) ListArtifacts(ctx context.Context, p ProjectSlug, num int) ([]Artifact, error) {
+	return c.artifacts(ctx, "ListArtifacts", p, p.params(), num)
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return make([]Artifact, 0), err
-	}
+func (c *client) artifacts(ctx context.Context, op string, p ProjectSlug, params map[string]string, num int) ([]Artifact, error) {
+	params["num"] = strconv.Itoa(num)
 
 	var a []Artifact
-	err = json.NewDecoder(response.Body).Decode(&a)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "GET",
+		path:   p.path(strconv.Itoa(num), "artifacts"),
+		params: params,
+	}, &a)
 	if err != nil {
 		return make([]Artifact, 0), err
 	}
@@ -480,50 +1168,89 @@ func (c *client) Artifacts(username, project string, num int) ([]Artifact, error
 
 // Information about a build.
 type Build struct {
-	Body            string      `json:"body"`
-	Branch          string      `json:"branch"`
-	BuildNum        int         `json:"build_num"`
-	BuildTimeMillis int         `json:"build_time_millis"`
-	BuildURL        string      `json:"build_url"`
-	CommitterEmail  string      `json:"committer_email"`
-	CommitterName   string      `json:"committer_name"`
-	DontBuild       string      `json:"dont_build"`
-	Lifecycle       string      `json:"lifecycle"`
-	Outcome         interface{} `json:"outcome"`
-	Previous        struct {
-		BuildNum int    `json:"build_num"`
-		Status   string `json:"status"`
-	} `json:"previous"`
-	QueuedAt    string `json:"queued_at"`
-	Reponame    string `json:"reponame"`
-	RetryOf     int    `json:"retry_of"`
-	StartTime   string `json:"start_time"`
-	Status      string `json:"status"`
-	StopTime    string `json:"stop_time"`
-	Subject     string `json:"subject"`
-	Username    string `json:"username"`
-	VCSRevision string `json:"vcs_revision"`
-	VCSURL      string `json:"vcs_url"`
-	Why         string `json:"why"`
+	Body            string        `json:"body"`
+	Branch          string        `json:"branch"`
+	BuildNum        int           `json:"build_num"`
+	BuildTimeMillis int           `json:"build_time_millis"`
+	BuildURL        string        `json:"build_url"`
+	CommitterEmail  string        `json:"committer_email"`
+	CommitterName   string        `json:"committer_name"`
+	DontBuild       *string       `json:"dont_build"`
+	Lifecycle       Lifecycle     `json:"lifecycle"`
+	Outcome         Outcome       `json:"outcome"`
+	Previous        PreviousBuild `json:"previous"`
+	QueuedAt        Time          `json:"queued_at"`
+	Reponame        string        `json:"reponame"`
+	RetryOf         int           `json:"retry_of"`
+	StartTime       Time          `json:"start_time"`
+	Status          Status        `json:"status"`
+	StopTime        Time          `json:"stop_time"`
+	Subject         string        `json:"subject"`
+	Username        string        `json:"username"`
+	VCSRevision     string        `json:"vcs_revision"`
+	VCSURL          string        `json:"vcs_url"`
+	Why             string        `json:"why"`
+
+	// Members of the JSON object not known to this package.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Known members of the JSON object the model was decoded from.
+	members jsonMembers
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping unknown members in
+// Unknown.
+func (b *Build) UnmarshalJSON(data []byte) error {
+	return unmarshalModel(data, b, &b.Unknown, &b.members)
+}
+
+// MarshalJSON implements json.Marshaler, including the members in Unknown.
+func (b Build) MarshalJSON() ([]byte, error) {
+	return marshalModel(&b, b.Unknown, b.members)
+}
+
+// BuildTime returns how long the build ran for.
+func (b Build) BuildTime() time.Duration {
+	return millis(b.BuildTimeMillis)
+}
+
+// IsTerminal reports whether the build has stopped and will not change status
+// again.
+func (b Build) IsTerminal() bool {
+	return b.Status.IsTerminal()
+}
+
+// IsGreen reports whether the build passed.
+func (b Build) IsGreen() bool {
+	return b.Status.IsGreen()
+}
+
+// IsRunning reports whether the build is running.
+func (b Build) IsRunning() bool {
+	return b.Status.IsRunning()
 }
 
 func (c *client) Retry(username, project string, num int) (Build, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/%d/retry", username, project, num))
+	return c.RetryContext(context.Background(), username, project, num)
+}
 
-	request, err := http.NewRequest("POST", url, nil)
-	if err != nil {
-		return Build{}, err
-	}
+func (c *client) RetryContext(ctx context.Context, username, project string, num int) (Build, error) {
+	return c.restartBuild(ctx, "Retry", GitHubProject(username, project), legacyParams(username, project), num)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) RetryBuild(ctx context.Context, p ProjectSlug, num int) (Build, error) {
+	return c.restartBuild(ctx, "RetryBuild", p, p.params(), num)
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return Build{}, err
-	}
+func (c *client) restartBuild(ctx context.Context, op string, p ProjectSlug, params map[string]string, num int) (Build, error) {
+	params["num"] = strconv.Itoa(num)
 
 	var b Build
-	err = json.NewDecoder(response.Body).Decode(&b)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "POST",
+		path:   p.path(strconv.Itoa(num), "retry"),
+		params: params,
+	}, &b)
 	if err != nil {
 		return Build{}, err
 	}
@@ -533,22 +1260,27 @@ func (c *client) Retry(username, project string, num int) (Build, error) {
 
 // Information about a build.
 func (c *client) Cancel(username, project string, num int) (Build, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/%d/cancel", username, project, num))
+	return c.CancelContext(context.Background(), username, project, num)
+}
 
-	request, err := http.NewRequest("POST", url, nil)
-	if err != nil {
-		return Build{}, err
-	}
+func (c *client) CancelContext(ctx context.Context, username, project string, num int) (Build, error) {
+	return c.stopBuild(ctx, "Cancel", GitHubProject(username, project), legacyParams(username, project), num)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) CancelBuild(ctx context.Context, p ProjectSlug, num int) (Build, error) {
+	return c.stopBuild(ctx, "CancelBuild", p, p.params(), num)
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return Build{}, err
-	}
+func (c *client) stopBuild(ctx context.Context, op string, p ProjectSlug, params map[string]string, num int) (Build, error) {
+	params["num"] = strconv.Itoa(num)
 
 	var b Build
-	err = json.NewDecoder(response.Body).Decode(&b)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "POST",
+		path:   p.path(strconv.Itoa(num), "cancel"),
+		params: params,
+	}, &b)
 	if err != nil {
 		return Build{}, err
 	}
@@ -557,51 +1289,109 @@ func (c *client) Cancel(username, project string, num int) (Build, error) {
 }
 
 func (c *client) Build(username, project, branch string) (Build, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/tree/%s", username, project, branch))
+	return c.BuildContext(context.Background(), username, project, branch)
+}
 
-	request, err := http.NewRequest("POST", url, nil)
-	if err != nil {
-		return Build{}, err
-	}
+func (c *client) BuildContext(ctx context.Context, username, project, branch string) (Build, error) {
+	return c.newBuild(ctx, "Build", GitHubProject(username, project), legacyParams(username, project), TriggerBuildOptions{Branch: branch})
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) TriggerBuild(ctx context.Context, p ProjectSlug, opts TriggerBuildOptions) (Build, error) {
+	return c.newBuild(ctx, "TriggerBuild", p, p.params(), opts)
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return Build{}, err
+func (c *client) newBuild(ctx context.Context, op string, p ProjectSlug, params map[string]string, opts TriggerBuildOptions) (Build, error) {
+	if opts.Branch != "" && opts.Tag != nil {
+		return Build{}, errors.New("circle: a build cannot be triggered for both a branch and a tag")
+	}
+
+	call := call{
+		op:     op,
+		method: "POST",
+		path:   p.path(),
+		body:   opts.body(),
+		params: params,
+	}
+	if opts.Branch != "" {
+		call.path = p.path("tree", opts.Branch)
+		call.params["branch"] = opts.Branch
+	}
+	if opts.Tag != nil {
+		call.params["tag"] = *opts.Tag
 	}
 
 	var b Build
-	err = json.NewDecoder(response.Body).Decode(&b)
-	if err != nil {
+	if err := c.do(ctx, call, &b); err != nil {
 		return Build{}, err
 	}
 
 	return b, nil
 }
 
+// Options for triggering a new build. Unset options are left out of the
+// request, in which case CircleCI builds the head of the default branch.
+type TriggerBuildOptions struct {
+	// The branch to build.
+	Branch string
+	// The commit to build, which must be on the branch if one is given.
+	Revision *string
+	// The tag to build. It cannot be combined with Branch.
+	Tag *string
+	// The number of containers to build with, overriding the project's
+	// setting.
+	Parallel *int
+	// Environment variables set for the build, overriding those of the
+	// project.
+	BuildParameters map[string]string
+}
+
+// triggerBuildRequest is the body of a request for a new build.
+type triggerBuildRequest struct {
+	Revision        *string           `json:"revision,omitempty"`
+	Tag             *string           `json:"tag,omitempty"`
+	Parallel        *int              `json:"parallel,omitempty"`
+	BuildParameters map[string]string `json:"build_parameters,omitempty"`
+}
+
+// body returns the options as a request body, or nil if no options that go in
+// the body are set.
+func (o TriggerBuildOptions) body() interface{} {
+	if o.Revision == nil && o.Tag == nil && o.Parallel == nil && len(o.BuildParameters) == 0 {
+		return nil
+	}
+	return triggerBuildRequest{
+		Revision:        o.Revision,
+		Tag:             o.Tag,
+		Parallel:        o.Parallel,
+		BuildParameters: o.BuildParameters,
+	}
+}
+
 // Response type indicating the status of clearing the cache.
 type ClearCacheResponse struct {
 	Status string `json:"status"`
 }
 
 func (c *client) ClearCache(username, project string) (ClearCacheResponse, error) {
-	url := c.endpoint(fmt.Sprintf("/project/%s/%s/build-cache", username, project))
-
-	request, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return ClearCacheResponse{}, err
-	}
+	return c.ClearCacheContext(context.Background(), username, project)
+}
 
-	request.Header.Set("Accept", "application/json")
+func (c *client) ClearCacheContext(ctx context.Context, username, project string) (ClearCacheResponse, error) {
+	return c.clearCache(ctx, "ClearCache", GitHubProject(username, project), legacyParams(username, project))
+}
 
-	response, err := c.http.Do(request)
-	if err != nil {
-		return ClearCacheResponse{}, err
-	}
+func (c *client) ClearProjectCache(ctx context.Context, p ProjectSlug) (ClearCacheResponse, error) {
+	return c.clearCache(ctx, "ClearProjectCache", p, p.params())
+}
 
+func (c *client) clearCache(ctx context.Context, op string, p ProjectSlug, params map[string]string) (ClearCacheResponse, error) {
 	var res ClearCacheResponse
-	err = json.NewDecoder(response.Body).Decode(&res)
+	err := c.do(ctx, call{
+		op:     op,
+		method: "DELETE",
+		path:   p.path("build-cache"),
+		params: params,
+	}, &res)
 	if err != nil {
 		return ClearCacheResponse{}, err
 	}
@@ -0,0 +1,348 @@
+package circle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// decodeFixture decodes the JSON file `name` in testdata into `v`.
+func decodeFixture(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding %s: %v", name, err)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+func boolPtr(b bool) *bool       { return &b }
+func intPtr(i int) *int          { return &i }
+
+func TestDecodeMe(t *testing.T) {
+	var m Me
+	decodeFixture(t, "me.json", &m)
+
+	if m.Login != "jane" || m.GithubID != 1234567 {
+		t.Errorf("Login, GithubID = %q, %d", m.Login, m.GithubID)
+	}
+	if m.GravatarID != nil || m.HerokuAPIKey != nil {
+		t.Errorf("GravatarID, HerokuAPIKey = %v, %v, want nil", m.GravatarID, m.HerokuAPIKey)
+	}
+	if !reflect.DeepEqual(m.Plan, stringPtr("oss")) {
+		t.Errorf("Plan = %v, want oss", m.Plan)
+	}
+	if got := m.Projects["https://github.com/jane/example"]; got.Emails != "default" || !got.OnDashboard {
+		t.Errorf("Projects = %+v", m.Projects)
+	}
+	if !m.CreatedAt.Equal(time.Date(2014, 4, 8, 7, 18, 24, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %v", m.CreatedAt)
+	}
+	if len(m.Unknown) != 0 {
+		t.Errorf("Unknown = %v, want none", m.Unknown)
+	}
+}
+
+func TestDecodeProject(t *testing.T) {
+	var p Project
+	decodeFixture(t, "project.json", &p)
+
+	if p.AWS.KeyPair != nil {
+		t.Errorf("AWS.KeyPair = %+v, want nil", p.AWS.KeyPair)
+	}
+	if p.CampfireRoom != nil || p.IRCPassword != nil || p.SlackAPIToken != nil {
+		t.Error("unset notification settings should be nil")
+	}
+	if !reflect.DeepEqual(p.SlackChannel, stringPtr("#builds")) {
+		t.Errorf("SlackChannel = %v, want #builds", p.SlackChannel)
+	}
+	if !reflect.DeepEqual(p.HipChatNotify, boolPtr(true)) {
+		t.Errorf("HipChatNotify = %v, want true", p.HipChatNotify)
+	}
+	if !p.FeatureFlags["oss"] || p.FeatureFlags["trusty-beta"] {
+		t.Errorf("FeatureFlags = %v", p.FeatureFlags)
+	}
+	master := p.Branches["master"]
+	if master.BuildNum != 1021 || master.Status != StatusSuccess || master.Outcome != OutcomeSuccess {
+		t.Errorf("Branches[master] = %+v", master)
+	}
+	if len(p.SSHKeys) != 1 ||
+		p.SSHKeys[0].Fingerprint != "c9:0b:1c:4f:d5:65:56:b9:ad:88:f9:81:2b:37:74:2f" ||
+		p.SSHKeys[0].Hostname != "deploy.example.com" ||
+		p.SSHKeys[0].PublicKey != "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC" {
+		t.Errorf("SSHKeys = %+v", p.SSHKeys)
+	}
+}
+
+func TestDecodeBuildSummary(t *testing.T) {
+	var builds []BuildSummary
+	decodeFixture(t, "build_summaries.json", &builds)
+	if len(builds) != 2 {
+		t.Fatalf("decoded %d builds, want 2", len(builds))
+	}
+
+	finished, running := builds[0], builds[1]
+
+	if c := finished.Canceler; c == nil || c.Email != "jane@example.com" || !c.IsUser || c.Login != "jane" || c.Name != "Jane Doe" {
+		t.Errorf("Canceler = %+v", c)
+	}
+	if running.Canceler != nil {
+		t.Errorf("Canceler = %+v, want nil", running.Canceler)
+	}
+
+	if !reflect.DeepEqual(finished.Retries, []int{1022, 1023}) {
+		t.Errorf("Retries = %v, want [1022 1023]", finished.Retries)
+	}
+	if running.Retries != nil {
+		t.Errorf("Retries = %v, want nil", running.Retries)
+	}
+
+	if u := finished.SSHUsers; len(u) != 1 || u[0].GithubID != 1234567 || u[0].Login != "jane" {
+		t.Errorf("SSHUsers = %+v", u)
+	}
+	if running.SSHUsers == nil || len(running.SSHUsers) != 0 {
+		t.Errorf("SSHUsers = %#v, want empty", running.SSHUsers)
+	}
+
+	wantParameters := map[string]json.RawMessage{
+		"DEPLOY":  json.RawMessage(`"true"`),
+		"RETRIES": json.RawMessage(`3`),
+	}
+	if !reflect.DeepEqual(finished.BuildParameters, wantParameters) {
+		t.Errorf("BuildParameters = %s, want %s", finished.BuildParameters, wantParameters)
+	}
+	if m := finished.Messages; len(m) != 1 || m[0].Message != "Build was canceled" || m[0].Reason != "canceled" || m[0].Type != "warning" {
+		t.Errorf("Messages = %+v", m)
+	}
+	if finished.Status != StatusCanceled || finished.Outcome != OutcomeCanceled || finished.Lifecycle != LifecycleFinished {
+		t.Errorf("Status, Outcome, Lifecycle = %q, %q, %q", finished.Status, finished.Outcome, finished.Lifecycle)
+	}
+	if finished.BuildTime() != 81024*time.Millisecond {
+		t.Errorf("BuildTime = %v", finished.BuildTime())
+	}
+	if !running.StopTime.IsZero() || !running.AuthorDate.IsZero() {
+		t.Errorf("StopTime, AuthorDate = %v, %v, want zero", running.StopTime, running.AuthorDate)
+	}
+
+	// Nullable members, either null, absent or set.
+	nullable := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"finished.DontBuild", finished.DontBuild, (*string)(nil)},
+		{"running.DontBuild", running.DontBuild, stringPtr("prs-only")},
+		{"finished.JobName", finished.JobName, (*string)(nil)},
+		{"running.JobName", running.JobName, stringPtr("build")},
+		{"finished.Failed", finished.Failed, (*bool)(nil)},
+		{"running.Failed", running.Failed, boolPtr(true)},
+		{"finished.SSHEnabled", finished.SSHEnabled, boolPtr(true)},
+		{"running.SSHEnabled (absent)", running.SSHEnabled, (*bool)(nil)},
+		{"finished.Node[0].SSHEnabled", finished.Node[0].SSHEnabled, (*bool)(nil)},
+	}
+	for _, n := range nullable {
+		if !reflect.DeepEqual(n.got, n.want) {
+			t.Errorf("%s = %v, want %v", n.name, n.got, n.want)
+		}
+	}
+}
+
+func TestDecodeDetailedBuildSummary(t *testing.T) {
+	var b DetailedBuildSummary
+	decodeFixture(t, "detailed_build_summary.json", &b)
+
+	if b.BuildNum != 1021 || b.Status != StatusFailed {
+		t.Errorf("BuildNum, Status = %d, %q", b.BuildNum, b.Status)
+	}
+	if !reflect.DeepEqual(b.Owners, []string{"jane"}) {
+		t.Errorf("Owners = %v", b.Owners)
+	}
+	if len(b.Steps) != 2 {
+		t.Fatalf("decoded %d steps, want 2", len(b.Steps))
+	}
+	if b.Retries != nil || b.Canceler != nil || b.SSHEnabled != nil {
+		t.Errorf("Retries, Canceler, SSHEnabled = %v, %v, %v, want nil", b.Retries, b.Canceler, b.SSHEnabled)
+	}
+
+	// Members this package does not know are kept by the model that has
+	// them, whether it is the embedded BuildSummary or not.
+	for _, name := range []string{"all_commit_details_truncated", "picard", "platform", "vcs_tag"} {
+		if _, ok := b.Unknown[name]; !ok {
+			t.Errorf("Unknown lacks %q", name)
+		}
+	}
+	if len(b.BuildSummary.Unknown) != 0 {
+		t.Errorf("BuildSummary.Unknown = %v, want none", b.BuildSummary.Unknown)
+	}
+}
+
+func TestDecodeAction(t *testing.T) {
+	var b DetailedBuildSummary
+	decodeFixture(t, "detailed_build_summary.json", &b)
+
+	start, test := b.Steps[0].Actions[0], b.Steps[1].Actions[0]
+
+	nullable := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"start.ExitCode", start.ExitCode, (*int)(nil)},
+		{"test.ExitCode", test.ExitCode, intPtr(2)},
+		{"start.Failed", start.Failed, (*bool)(nil)},
+		{"test.Failed", test.Failed, boolPtr(true)},
+		{"test.Timedout", test.Timedout, (*bool)(nil)},
+		{"start.BashCommand", start.BashCommand, (*string)(nil)},
+		{"test.BashCommand", test.BashCommand, stringPtr("make test")},
+	}
+	for _, n := range nullable {
+		if !reflect.DeepEqual(n.got, n.want) {
+			t.Errorf("%s = %v, want %v", n.name, n.got, n.want)
+		}
+	}
+
+	if test.Status != StatusFailed || test.RunTime() != 80913*time.Millisecond {
+		t.Errorf("Status, RunTime = %q, %v", test.Status, test.RunTime())
+	}
+	if test.OutputURL == "" || !test.HasOutput || !test.Parallel {
+		t.Errorf("OutputURL, HasOutput, Parallel = %q, %v, %v", test.OutputURL, test.HasOutput, test.Parallel)
+	}
+	if _, ok := test.Unknown["source"]; !ok {
+		t.Errorf("Unknown lacks %q", "source")
+	}
+}
+
+func TestDecodeBuild(t *testing.T) {
+	var b Build
+	decodeFixture(t, "build.json", &b)
+
+	if b.BuildNum != 1024 || b.RetryOf != 1021 || b.Status != StatusNotRunning || b.Lifecycle != LifecycleNotRunning {
+		t.Errorf("BuildNum, RetryOf, Status, Lifecycle = %d, %d, %q, %q", b.BuildNum, b.RetryOf, b.Status, b.Lifecycle)
+	}
+	if b.DontBuild != nil {
+		t.Errorf("DontBuild = %v, want nil", b.DontBuild)
+	}
+	if b.Outcome != "" || !b.StartTime.IsZero() || !b.StopTime.IsZero() {
+		t.Errorf("Outcome, StartTime, StopTime = %q, %v, %v, want zero", b.Outcome, b.StartTime, b.StopTime)
+	}
+	if p := b.Previous; p.BuildNum != 1021 || p.BuildTimeMillis != 81024 || p.Status != StatusFailed {
+		t.Errorf("Previous = %+v", p)
+	}
+}
+
+func TestTriggerBuild(t *testing.T) {
+	type request struct {
+		method, path, contentType string
+		body                      map[string]json.RawMessage
+	}
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.EscapedPath(), contentType: r.Header.Get("Content-Type")}
+		b, _ := io.ReadAll(r.Body)
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &req.body); err != nil {
+				t.Errorf("decoding body %s: %v", b, err)
+			}
+		}
+		requests = append(requests, req)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"build_num":12}`))
+	}))
+	defer srv.Close()
+	c := New("token", WithBaseURL(srv.URL))
+	ctx := context.Background()
+	p := GitHubProject("org", "repo")
+
+	tests := []struct {
+		name     string
+		opts     TriggerBuildOptions
+		wantPath string
+		wantBody map[string]string
+	}{
+		{
+			name:     "no options",
+			wantPath: "/v1.1/project/github/org/repo",
+		},
+		{
+			name:     "branch only",
+			opts:     TriggerBuildOptions{Branch: "feature/x"},
+			wantPath: "/v1.1/project/github/org/repo/tree/feature%2Fx",
+		},
+		{
+			name: "every option",
+			opts: TriggerBuildOptions{
+				Branch:          "master",
+				Revision:        stringPtr("b59ef0e"),
+				Parallel:        intPtr(4),
+				BuildParameters: map[string]string{"DEPLOY": "true"},
+			},
+			wantPath: "/v1.1/project/github/org/repo/tree/master",
+			wantBody: map[string]string{
+				"revision":         `"b59ef0e"`,
+				"parallel":         `4`,
+				"build_parameters": `{"DEPLOY":"true"}`,
+			},
+		},
+		{
+			name:     "tag",
+			opts:     TriggerBuildOptions{Tag: stringPtr("v1.0.0")},
+			wantPath: "/v1.1/project/github/org/repo",
+			wantBody: map[string]string{"tag": `"v1.0.0"`},
+		},
+		{
+			name:     "zero parallelism is sent",
+			opts:     TriggerBuildOptions{Parallel: intPtr(0), BuildParameters: map[string]string{}},
+			wantPath: "/v1.1/project/github/org/repo",
+			wantBody: map[string]string{"parallel": `0`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			b, err := c.TriggerBuild(ctx, p, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.BuildNum != 12 {
+				t.Errorf("BuildNum = %d, want 12", b.BuildNum)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(requests))
+			}
+			r := requests[0]
+			if r.method != "POST" || r.path != tt.wantPath {
+				t.Errorf("sent %s %s, want POST %s", r.method, r.path, tt.wantPath)
+			}
+			if tt.wantBody == nil {
+				if r.body != nil || r.contentType != "" {
+					t.Errorf("sent a %q body %s, want none", r.contentType, r.body)
+				}
+				return
+			}
+			got := make(map[string]string, len(r.body))
+			for name, value := range r.body {
+				got[name] = string(value)
+			}
+			if !reflect.DeepEqual(got, tt.wantBody) {
+				t.Errorf("sent body %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+
+	requests = nil
+	_, err := c.TriggerBuild(ctx, p, TriggerBuildOptions{Branch: "master", Tag: stringPtr("v1.0.0")})
+	if err == nil {
+		t.Error("expected an error for both a branch and a tag")
+	}
+	if len(requests) != 0 {
+		t.Errorf("sent %d requests, want none", len(requests))
+	}
+}
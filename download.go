@@ -0,0 +1,370 @@
+package circle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultDownloadConcurrency is the number of artifacts DownloadArtifacts
+// downloads at once by default.
+const defaultDownloadConcurrency = 4
+
+// partialSuffix is appended to the name of files being downloaded.
+const partialSuffix = ".part"
+
+// Options for DownloadArtifacts.
+type DownloadOptions struct {
+	// Only download artifacts whose path matches this pattern, in the syntax
+	// of path.Match, e.g. `reports/*.xml`. All artifacts are downloaded if it
+	// is empty.
+	Pattern string
+	// Only download artifacts of the container with this index.
+	NodeIndex *int
+	// Maximum number of artifacts downloaded at once. Defaults to 4.
+	Concurrency int
+}
+
+// Result of downloading an artifact with DownloadArtifacts.
+type DownloadResult struct {
+	Artifact Artifact
+	// Local path of the downloaded file.
+	Path string
+	// Size of the file in bytes.
+	Size int64
+	// Hex encoded SHA-256 checksum of the file.
+	SHA256 string
+	// The file had been downloaded before, and was left untouched.
+	Skipped bool
+	// The download continued from a partially downloaded file.
+	Resumed bool
+}
+
+// RelativePath returns the path of the artifact within the build's artifacts
+// directory, i.e. PrettyPath without the `$CIRCLE_ARTIFACTS/` prefix.
+func (a Artifact) RelativePath() string {
+	return strings.TrimPrefix(strings.TrimPrefix(a.PrettyPath, "$CIRCLE_ARTIFACTS"), "/")
+}
+
+// Part of an artifact, as returned by DownloadArtifactRange.
+type ArtifactRange struct {
+	// Content of the artifact from Offset onwards, which the caller must
+	// close. It is http.NoBody if the requested offset is at or past the end
+	// of the artifact.
+	Body io.ReadCloser
+	// Offset of the first byte of Body within the artifact. It is 0 rather
+	// than the requested offset if the server sent the whole artifact.
+	Offset int64
+	// Size of the whole artifact in bytes, or -1 if the server did not report
+	// it.
+	Size int64
+}
+
+func (c *client) DownloadArtifact(ctx context.Context, a Artifact) (io.ReadCloser, error) {
+	response, err := c.getArtifact(ctx, "DownloadArtifact", a, 0)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (c *client) DownloadArtifactRange(ctx context.Context, a Artifact, offset int64) (ArtifactRange, error) {
+	response, err := c.getArtifact(ctx, "DownloadArtifactRange", a, offset)
+	if err != nil {
+		return ArtifactRange{}, err
+	}
+
+	r := ArtifactRange{Body: response.Body, Size: -1}
+	switch response.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The response reports the size of the artifact as `bytes */size`,
+		// if at all.
+		drainAndClose(response.Body)
+		r.Body, r.Offset = http.NoBody, offset
+		if _, size, err := parseContentRange(response.Header.Get("Content-Range")); err == nil {
+			r.Size = size
+		}
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(response.Header.Get("Content-Range"))
+		if err != nil || start < 0 {
+			response.Body.Close()
+			return ArtifactRange{}, fmt.Errorf("circle: invalid Content-Range %q", response.Header.Get("Content-Range"))
+		}
+		r.Offset, r.Size = start, size
+	default:
+		r.Size = response.ContentLength
+	}
+	return r, nil
+}
+
+// getArtifact requests the artifact `a` from `offset` onwards.
+func (c *client) getArtifact(ctx context.Context, op string, a Artifact, offset int64) (*http.Response, error) {
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return nil, fmt.Errorf("circle: invalid artifact URL: %v", c.redact(err))
+	}
+
+	call := call{
+		op:     op,
+		method: "GET",
+		url:    a.URL,
+		params: map[string]string{
+			"path": a.PrettyPath,
+			"node": strconv.Itoa(a.NodeIndex),
+		},
+		// Artifacts need the token, but it is only sent to CircleCI itself.
+		anonymous: !c.isCircleHost(u.Hostname()),
+	}
+	if offset > 0 {
+		call.params["offset"] = strconv.FormatInt(offset, 10)
+		call.header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+		call.allowStatus = []int{http.StatusRequestedRangeNotSatisfiable}
+	}
+	return c.stream(ctx, call)
+}
+
+// parseContentRange parses a Content-Range header such as `bytes 0-99/1000`
+// or `bytes */1000`. It returns the offset of the first byte of the range, or
+// -1 if there is none, and the size of the whole resource, or -1 if unknown.
+func parseContentRange(s string) (start, size int64, err error) {
+	invalid := fmt.Errorf("circle: invalid Content-Range %q", s)
+	rest, ok := strings.CutPrefix(s, "bytes ")
+	if !ok {
+		return 0, 0, invalid
+	}
+	rng, total, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, invalid
+	}
+
+	start, size = -1, -1
+	if rng != "*" {
+		first, _, ok := strings.Cut(rng, "-")
+		if start, err = strconv.ParseInt(first, 10, 64); !ok || err != nil {
+			return 0, 0, invalid
+		}
+	}
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	return start, size, nil
+}
+
+// isCircleHost reports whether `host` belongs to the CircleCI install the
+// client talks to, and may be sent the API token.
+func (c *client) isCircleHost(host string) bool {
+	host = strings.ToLower(host)
+	if base, err := url.Parse(c.baseURL); err == nil && strings.EqualFold(base.Hostname(), host) {
+		return true
+	}
+	for _, domain := range []string{"circleci.com", "circle-artifacts.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadArtifacts downloads the artifacts of the build `num` of the project
+// `p` selected by `opts` into `dir` with `c`, at their RelativePath. When
+// artifacts of more than one container are downloaded, each container's are
+// put in a directory named after its index.
+//
+// Files that already exist are skipped, and partial downloads left by an
+// earlier attempt are resumed. The size of every download is verified against
+// the size reported by the server. It returns the artifacts that were
+// downloaded along with the errors of those that were not.
+func DownloadArtifacts(ctx context.Context, c CircleCI, p ProjectSlug, num int, dir string, opts DownloadOptions) ([]DownloadResult, error) {
+	artifacts, err := c.ListArtifacts(ctx, p, num)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []Artifact
+	nodes := make(map[int]bool)
+	for _, a := range artifacts {
+		if opts.NodeIndex != nil && a.NodeIndex != *opts.NodeIndex {
+			continue
+		}
+		if opts.Pattern != "" {
+			ok, err := path.Match(opts.Pattern, a.RelativePath())
+			if err != nil {
+				return nil, fmt.Errorf("circle: invalid artifact pattern %q: %v", opts.Pattern, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		selected = append(selected, a)
+		nodes[a.NodeIndex] = true
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []DownloadResult
+		errs    []error
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, a := range selected {
+		// Artifacts of different containers may share a path, so they are
+		// kept apart when more than one container is downloaded from.
+		file, err := localPath(dir, a, len(nodes) > 1)
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(a Artifact, file string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				errs = append(errs, ctx.Err())
+				mu.Unlock()
+				return
+			}
+
+			result, err := downloadTo(ctx, c, a, file)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("circle: downloading %s: %w", a.PrettyPath, err))
+				return
+			}
+			results = append(results, result)
+		}(a, file)
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// localPath returns the path of the artifact `a` within `dir`, refusing
+// paths that would escape it.
+func localPath(dir string, a Artifact, perNode bool) (string, error) {
+	name := path.Clean("/" + a.RelativePath())
+	if name == "/" {
+		return "", fmt.Errorf("circle: invalid artifact path %q", a.PrettyPath)
+	}
+	if perNode {
+		name = path.Join(strconv.Itoa(a.NodeIndex), name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
+
+// downloadTo downloads the artifact `a` to `file` with `c`, resuming from a
+// partial download if there is one.
+func downloadTo(ctx context.Context, c CircleCI, a Artifact, file string) (DownloadResult, error) {
+	result := DownloadResult{Artifact: a, Path: file}
+
+	if info, err := os.Stat(file); err == nil {
+		sum, err := checksumFile(file, sha256.New())
+		if err != nil {
+			return result, err
+		}
+		result.Size, result.SHA256, result.Skipped = info.Size(), sum, true
+		return result, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return result, err
+	}
+	partial := file + partialSuffix
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	r, err := c.DownloadArtifactRange(ctx, a, offset)
+	if err != nil {
+		return result, err
+	}
+	if offset > 0 && r.Body == http.NoBody && r.Size != offset {
+		// The partial download is longer than the artifact, or cannot be
+		// checked against its size, so start over.
+		offset = 0
+		if r, err = c.DownloadArtifactRange(ctx, a, 0); err != nil {
+			return result, err
+		}
+	}
+	defer r.Body.Close()
+	if r.Offset != 0 && r.Offset != offset {
+		return result, fmt.Errorf("server sent the artifact from byte %d, requested %d", r.Offset, offset)
+	}
+
+	h := sha256.New()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if r.Offset > 0 {
+		if _, err := checksumFile(partial, h); err != nil {
+			return result, err
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+		result.Resumed = true
+	}
+
+	f, err := os.OpenFile(partial, flags, 0o644)
+	if err != nil {
+		return result, err
+	}
+	n, err := io.Copy(io.MultiWriter(f, h), r.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	size := r.Offset + n
+	if r.Size >= 0 && size != r.Size {
+		if size > r.Size {
+			// A partial download that cannot be resumed from.
+			os.Remove(partial)
+		}
+		return result, fmt.Errorf("downloaded %d bytes, expected %d", size, r.Size)
+	}
+	if err := os.Rename(partial, file); err != nil {
+		return result, err
+	}
+
+	result.Size, result.SHA256 = size, hex.EncodeToString(h.Sum(nil))
+	return result, nil
+}
+
+// checksumFile writes the contents of `file` to `h` and returns its hex
+// encoded sum.
+func checksumFile(file string, h hash.Hash) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,294 @@
+package circle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var artifactContent = strings.Repeat("0123456789", 100)
+
+// artifactServer lists `artifacts` as those of build 1 of gh/org/repo, with
+// only their PrettyPath and NodeIndex set from them, and serves their content
+// with `serve`. The URL of each artifact is /artifacts/<i>, where i is its
+// position in `artifacts`.
+func artifactServer(t *testing.T, serve http.HandlerFunc, artifacts ...Artifact) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.1/project/github/org/repo/1/artifacts" {
+			w.Header().Set("Content-Type", "application/json")
+			var list []string
+			for _, a := range artifacts {
+				list = append(list, fmt.Sprintf(`{"path":"/tmp/circle-artifacts/x","pretty_path":%q,"node_index":%d,"url":"%s/artifacts/%d"}`,
+					a.PrettyPath, a.NodeIndex, srv.URL, len(list)))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(list, ","))
+			return
+		}
+		serve(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// serveContent serves artifactContent, supporting ranges.
+func serveContent(w http.ResponseWriter, r *http.Request) {
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(artifactContent))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkFile(t *testing.T, name, content string) {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Errorf("%s holds %d bytes, want %d", name, len(b), len(content))
+	}
+	if _, err := os.Stat(name + partialSuffix); !os.IsNotExist(err) {
+		t.Errorf("partial download of %s left behind", name)
+	}
+}
+
+func TestDownloadArtifactsResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		partial     string
+		serve       http.HandlerFunc
+		wantResumed bool
+	}{
+		{"no partial download", "", serveContent, false},
+		{"resumed", artifactContent[:300], serveContent, true},
+		{"complete partial download", artifactContent, serveContent, true},
+		{"partial download too long", artifactContent + "extra", serveContent, false},
+		{
+			"range ignored",
+			"garbage",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(artifactContent))
+			},
+			false,
+		},
+		{
+			"range not satisfiable without size",
+			artifactContent,
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "" {
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+				w.Write([]byte(artifactContent))
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := artifactServer(t, tt.serve, Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/logs/a.txt"})
+			c := New("token", WithBaseURL(srv.URL))
+			dir := t.TempDir()
+			file := filepath.Join(dir, "logs", "a.txt")
+			if tt.partial != "" {
+				writeFile(t, file+partialSuffix, tt.partial)
+			}
+
+			results, err := DownloadArtifacts(context.Background(), c, GitHubProject("org", "repo"), 1, dir, DownloadOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("downloaded %d artifacts, want 1", len(results))
+			}
+			r := results[0]
+			if r.Path != file || r.Size != int64(len(artifactContent)) || r.SHA256 != sha256Hex(artifactContent) {
+				t.Errorf("result = %+v", r)
+			}
+			if r.Resumed != tt.wantResumed || r.Skipped {
+				t.Errorf("Resumed, Skipped = %v, %v, want %v, false", r.Resumed, r.Skipped, tt.wantResumed)
+			}
+			checkFile(t, file, artifactContent)
+		})
+	}
+}
+
+func TestDownloadArtifactsSizeMismatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		partial     string
+		serve       http.HandlerFunc
+		keepPartial bool
+	}{
+		{
+			"short range",
+			artifactContent[:300],
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes 300-999/2000")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(artifactContent[300:]))
+			},
+			true,
+		},
+		{
+			"long range",
+			artifactContent[:300],
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes 300-999/500")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(artifactContent[300:]))
+			},
+			false,
+		},
+		{
+			"wrong offset",
+			artifactContent[:300],
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes 200-999/1000")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(artifactContent[200:]))
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := artifactServer(t, tt.serve, Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/a.txt"})
+			c := New("token", WithBaseURL(srv.URL))
+			dir := t.TempDir()
+			file := filepath.Join(dir, "a.txt")
+			writeFile(t, file+partialSuffix, tt.partial)
+
+			results, err := DownloadArtifacts(context.Background(), c, GitHubProject("org", "repo"), 1, dir, DownloadOptions{})
+			if err == nil || len(results) != 0 {
+				t.Fatalf("results, err = %+v, %v, want an error", results, err)
+			}
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("%s was created", file)
+			}
+			if _, err := os.Stat(file + partialSuffix); (err == nil) != tt.keepPartial {
+				t.Errorf("partial download kept: %v, want %v", err == nil, tt.keepPartial)
+			}
+		})
+	}
+}
+
+func TestDownloadArtifactsSelection(t *testing.T) {
+	srv := artifactServer(t, serveContent,
+		Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/reports/a.xml", NodeIndex: 0},
+		Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/reports/a.xml", NodeIndex: 1},
+		Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/coverage.html", NodeIndex: 0},
+		Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/../../escape.txt", NodeIndex: 0},
+	)
+	c := New("token", WithBaseURL(srv.URL))
+	p := GitHubProject("org", "repo")
+	node := 1
+
+	tests := []struct {
+		name string
+		opts DownloadOptions
+		want []string
+	}{
+		{"all", DownloadOptions{}, []string{"0/reports/a.xml", "1/reports/a.xml", "0/coverage.html", "0/escape.txt"}},
+		{"pattern", DownloadOptions{Pattern: "reports/*.xml"}, []string{"0/reports/a.xml", "1/reports/a.xml"}},
+		{"node", DownloadOptions{NodeIndex: &node}, []string{"reports/a.xml"}},
+		{"pattern on one node", DownloadOptions{Pattern: "*.html", Concurrency: 1}, []string{"coverage.html"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			results, err := DownloadArtifacts(context.Background(), c, p, 1, dir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("downloaded %d artifacts, want %d", len(results), len(tt.want))
+			}
+			for _, name := range tt.want {
+				checkFile(t, filepath.Join(dir, filepath.FromSlash(name)), artifactContent)
+			}
+		})
+	}
+
+	if _, err := DownloadArtifacts(context.Background(), c, p, 1, t.TempDir(), DownloadOptions{Pattern: "["}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestDownloadArtifactsSkipsExisting(t *testing.T) {
+	srv := artifactServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("existing artifact downloaded again")
+	}, Artifact{PrettyPath: "$CIRCLE_ARTIFACTS/a.txt"})
+	c := New("token", WithBaseURL(srv.URL))
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "existing")
+
+	results, err := DownloadArtifacts(context.Background(), c, GitHubProject("org", "repo"), 1, dir, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Skipped || results[0].SHA256 != sha256Hex("existing") {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		in          string
+		start, size int64
+		ok          bool
+	}{
+		{"bytes 0-99/1000", 0, 1000, true},
+		{"bytes 300-999/*", 300, -1, true},
+		{"bytes */1000", -1, 1000, true},
+		{"bytes */*", -1, -1, true},
+		{"", 0, 0, false},
+		{"bytes 300-999", 0, 0, false},
+		{"items 0-9/10", 0, 0, false},
+		{"bytes x-9/10", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, size, err := parseContentRange(tt.in)
+		if (err == nil) != tt.ok || start != tt.start || size != tt.size {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", tt.in, start, size, err)
+		}
+	}
+}
+
+func TestIsCircleHost(t *testing.T) {
+	c := New("token", WithBaseURL("https://circle.example.com/api")).(*client)
+	for host, want := range map[string]bool{
+		"circle.example.com":                true,
+		"circleci.com":                      true,
+		"CircleCI.com":                      true,
+		"1234-5678-gh.circle-artifacts.com": true,
+		"circle-production-action-output.s3.amazonaws.com": false,
+		"evilcircleci.com": false,
+		"example.com":      false,
+	} {
+		if got := c.isCircleHost(host); got != want {
+			t.Errorf("isCircleHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package circle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// DecodeMode controls how the client handles responses that do not match the
+// models of this package, e.g. because CircleCI changed the type of a field.
+// Modes may be combined, e.g. `DecodeStrict | DecodeTolerant`. By default a
+// call fails if its response cannot be decoded.
+type DecodeMode uint
+
+const (
+	// DecodeStrict checks every response against the models, reporting
+	// unknown members and type mismatches as diagnostics. Members with
+	// mismatched types are left at their zero value (and mismatched list or
+	// map elements are left out) instead of failing the call.
+	DecodeStrict DecodeMode = 1 << iota
+
+	// DecodeTolerant skips elements of list responses, such as the builds
+	// returned by RecentBuilds, that cannot be decoded, reporting them as
+	// diagnostics instead of failing the call.
+	DecodeTolerant
+)
+
+// DiagnosticKind is the kind of problem described by a Diagnostic.
+type DiagnosticKind string
+
+// Kinds of problems reported by DecodeStrict and DecodeTolerant.
+const (
+	DiagnosticUnknownMember  DiagnosticKind = "unknown_member"
+	DiagnosticTypeMismatch   DiagnosticKind = "type_mismatch"
+	DiagnosticSkippedElement DiagnosticKind = "skipped_element"
+)
+
+// Diagnostic describes a difference between a response and the models of
+// this package.
+type Diagnostic struct {
+	// Name of the method that made the call, e.g. "RecentBuilds".
+	Operation string
+	Kind      DiagnosticKind
+	// Location of the problem in the response, e.g.
+	// `[3].all_commit_details[0].author_date`.
+	Path string
+	// Human readable description of the problem.
+	Detail string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s at %q: %s", d.Operation, d.Kind, d.Path, d.Detail)
+}
+
+// WithDecodeMode sets how responses that do not match the models are
+// handled, calling `report` with every diagnostic found. `report` may be
+// called concurrently by calls running in parallel.
+func WithDecodeMode(mode DecodeMode, report func(Diagnostic)) Option {
+	return func(c *client) {
+		c.decodeMode = mode
+		c.report = report
+	}
+}
+
+// decode decodes the response `body` of the operation `op` into `v`
+// according to the client's decode mode.
+func (c *client) decode(op string, body io.Reader, v interface{}) error {
+	if c.decodeMode == 0 {
+		return json.NewDecoder(body).Decode(v)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	report := func(kind DiagnosticKind, path, detail string) {
+		if c.report != nil {
+			c.report(Diagnostic{op, kind, path, detail})
+		}
+	}
+
+	if c.decodeMode&DecodeStrict != 0 {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		var value interface{}
+		if err := d.Decode(&value); err != nil {
+			return err
+		}
+		checker := schemaChecker{report}
+		value, _ = checker.check(value, reflect.TypeOf(v).Elem(), "")
+		if data, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	if c.decodeMode&DecodeTolerant == 0 || rv.Kind() != reflect.Slice {
+		return json.Unmarshal(data, v)
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
+	list := reflect.MakeSlice(rv.Type(), 0, len(elements))
+	for i, element := range elements {
+		ev := reflect.New(rv.Type().Elem())
+		if err := json.Unmarshal(element, ev.Interface()); err != nil {
+			report(DiagnosticSkippedElement, fmt.Sprintf("[%d]", i), err.Error())
+			continue
+		}
+		list = reflect.Append(list, ev.Elem())
+	}
+	rv.Set(list)
+	return nil
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	rawMessageType  = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemaChecker compares decoded JSON values against Go types.
+type schemaChecker struct {
+	report func(kind DiagnosticKind, path, detail string)
+}
+
+// check compares `value`, as decoded into an interface{} with numbers as
+// json.Number, against the type `t`. It returns the value with the parts that
+// do not match `t` removed, and false if the value as a whole does not match.
+func (c schemaChecker) check(value interface{}, t reflect.Type, path string) (interface{}, bool) {
+	if value == nil {
+		// null decodes into anything, leaving it untouched.
+		return nil, true
+	}
+
+	switch {
+	case t == rawMessageType || t.Kind() == reflect.Interface:
+		return value, true
+	case t.Kind() == reflect.Pointer:
+		return c.check(value, t.Elem(), path)
+	case reflect.PointerTo(t).Implements(unmarshalerType) && !isModel(t):
+		// Types such as Time decode themselves, so just try them.
+		b, _ := json.Marshal(value)
+		if err := json.Unmarshal(b, reflect.New(t).Interface()); err != nil {
+			return c.mismatch(t, value, path)
+		}
+		return value, true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return c.mismatch(t, value, path)
+		}
+		fields := modelFields(t)
+		for name, member := range object {
+			memberPath := joinPath(path, name)
+			f, ok := fields.lookup(name)
+			if !ok {
+				c.report(DiagnosticUnknownMember, memberPath, fmt.Sprintf("%s has no field for it", t.Name()))
+				continue
+			}
+			if cleaned, ok := c.check(member, t.FieldByIndex(f.index).Type, memberPath); ok {
+				object[name] = cleaned
+			} else {
+				delete(object, name)
+			}
+		}
+		return object, true
+
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return c.mismatch(t, value, path)
+		}
+		cleaned := make([]interface{}, 0, len(list))
+		for i, element := range list {
+			if v, ok := c.check(element, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); ok {
+				cleaned = append(cleaned, v)
+			}
+		}
+		return cleaned, true
+
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return c.mismatch(t, value, path)
+		}
+		for key, element := range object {
+			if cleaned, ok := c.check(element, t.Elem(), joinPath(path, key)); ok {
+				object[key] = cleaned
+			} else {
+				delete(object, key)
+			}
+		}
+		return object, true
+
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return c.mismatch(t, value, path)
+		}
+
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return c.mismatch(t, value, path)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return c.mismatch(t, value, path)
+		}
+		if _, err := strconv.ParseInt(string(n), 10, t.Bits()); err != nil {
+			return c.mismatch(t, value, path)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return c.mismatch(t, value, path)
+		}
+		if _, err := strconv.ParseUint(string(n), 10, t.Bits()); err != nil {
+			return c.mismatch(t, value, path)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			return c.mismatch(t, value, path)
+		}
+	}
+	return value, true
+}
+
+// mismatch reports that `value` does not match the type `t`.
+func (c schemaChecker) mismatch(t reflect.Type, value interface{}, path string) (interface{}, bool) {
+	c.report(DiagnosticTypeMismatch, path, fmt.Sprintf("expected %s, got %s", t, jsonKind(value)))
+	return nil, false
+}
+
+// isModel reports whether `t` is one of the models that keep unknown members,
+// which are checked field by field despite implementing json.Unmarshaler.
+func isModel(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := t.FieldByName("Unknown")
+	return ok && f.Type == reflect.TypeOf(map[string]json.RawMessage(nil))
+}
+
+// jsonKind describes the kind of a decoded JSON value.
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// joinPath appends the member `name` to the JSON path `path`.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
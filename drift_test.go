@@ -0,0 +1,163 @@
+package circle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// jsonServer responds to every request with the JSON `body`.
+func jsonServer(t *testing.T, body string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// recordDiagnostics returns a client for `srv` using the decode mode `mode`,
+// which appends the diagnostics it reports to `diags`.
+func recordDiagnostics(srv *httptest.Server, mode DecodeMode, diags *[]Diagnostic) CircleCI {
+	return New("token", WithBaseURL(srv.URL), WithDecodeMode(mode, func(d Diagnostic) {
+		*diags = append(*diags, d)
+	}))
+}
+
+// diagnosticPaths returns the kind and path of each of `diags`, sorted, as
+// "kind path".
+func diagnosticPaths(diags []Diagnostic) []string {
+	paths := make([]string, 0, len(diags))
+	for _, d := range diags {
+		paths = append(paths, string(d.Kind)+" "+d.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestDecodeStrict(t *testing.T) {
+	srv := jsonServer(t, `{
+		"login": "jane",
+		"containers": "two",
+		"all_emails": ["jane@example.com", 1],
+		"projects": {"https://github.com/jane/example": {"emails": "default", "on_dashboard": "yes", "muted": true}},
+		"created_at": "yesterday",
+		"trial_end": "2015-10-30T11:59:02.000Z",
+		"pronouns": "they/them"
+	}`)
+	var diags []Diagnostic
+	c := recordDiagnostics(srv, DecodeStrict, &diags)
+
+	m, err := c.Me()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"type_mismatch all_emails[1]",
+		"type_mismatch containers",
+		"type_mismatch created_at",
+		"type_mismatch projects.https://github.com/jane/example.on_dashboard",
+		"unknown_member projects.https://github.com/jane/example.muted",
+		"unknown_member pronouns",
+	}
+	if got := diagnosticPaths(diags); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+	for _, d := range diags {
+		if d.Operation != "Me" || d.Detail == "" {
+			t.Errorf("diagnostic %v lacks its operation or detail", d)
+		}
+	}
+
+	// The members that match are decoded, and unknown ones kept.
+	if m.Login != "jane" || m.Containers != 0 || !reflect.DeepEqual(m.Emails, []string{"jane@example.com"}) {
+		t.Errorf("Login, Containers, Emails = %q, %d, %q", m.Login, m.Containers, m.Emails)
+	}
+	if !m.CreatedAt.IsZero() || m.TrialEnd.IsZero() {
+		t.Errorf("CreatedAt, TrialEnd = %v, %v", m.CreatedAt, m.TrialEnd)
+	}
+	if p := m.Projects["https://github.com/jane/example"]; p.Emails != "default" || p.OnDashboard {
+		t.Errorf("Projects = %+v", m.Projects)
+	}
+	if _, ok := m.Unknown["pronouns"]; !ok {
+		t.Errorf("Unknown = %v, want pronouns", m.Unknown)
+	}
+}
+
+func TestDecodeTolerant(t *testing.T) {
+	body := `[
+		{"build_num": 1, "status": "success"},
+		{"build_num": "two", "status": "failed"},
+		{"build_num": 3, "status": "running", "start_time": "not a time"},
+		{"build_num": 4, "status": "queued"}
+	]`
+
+	tests := []struct {
+		name      string
+		mode      DecodeMode
+		wantBuild []int
+		wantDiags []string
+	}{
+		{
+			"tolerant",
+			DecodeTolerant,
+			[]int{1, 4},
+			[]string{"skipped_element [1]", "skipped_element [2]"},
+		},
+		{
+			"strict and tolerant",
+			DecodeStrict | DecodeTolerant,
+			[]int{1, 0, 3, 4},
+			[]string{"type_mismatch [1].build_num", "type_mismatch [2].start_time"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags []Diagnostic
+			c := recordDiagnostics(jsonServer(t, body), tt.mode, &diags)
+
+			builds, err := c.RecentBuilds()
+			if err != nil {
+				t.Fatal(err)
+			}
+			nums := make([]int, 0, len(builds))
+			for _, b := range builds {
+				nums = append(nums, b.BuildNum)
+			}
+			if !reflect.DeepEqual(nums, tt.wantBuild) {
+				t.Errorf("builds = %v, want %v", nums, tt.wantBuild)
+			}
+			if got := diagnosticPaths(diags); !reflect.DeepEqual(got, tt.wantDiags) {
+				t.Errorf("diagnostics = %q, want %q", got, tt.wantDiags)
+			}
+		})
+	}
+
+	// Without a decode mode, the call fails.
+	if _, err := New("token", WithBaseURL(jsonServer(t, body).URL)).RecentBuilds(); err == nil {
+		t.Error("expected an error without a decode mode")
+	}
+}
+
+func TestDecodeNoDrift(t *testing.T) {
+	srv := jsonServer(t, `[
+		{"build_num": 1, "status": "success", "start_time": "2015-10-30T11:59:02.000Z", "previous": {"build_num": 0}},
+		{"build_num": 2, "status": "running", "stop_time": null, "node": [{"image_id": "i"}]}
+	]`)
+	for _, mode := range []DecodeMode{DecodeStrict, DecodeTolerant, DecodeStrict | DecodeTolerant} {
+		var diags []Diagnostic
+		builds, err := recordDiagnostics(srv, mode, &diags).RecentBuilds()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(builds) != 2 || builds[1].Node[0].ImageID != "i" {
+			t.Errorf("mode %d: builds = %+v", mode, builds)
+		}
+		if len(diags) != 0 {
+			t.Errorf("mode %d: diagnostics = %v, want none", mode, diags)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package circle
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An environment variable of a project. CircleCI never returns the values of
+// environment variables, only masked versions of them (see MaskEnvValue).
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MaskEnvValue returns `value` masked the way CircleCI masks the values of
+// environment variables, which is `xxxx` followed by its last four
+// characters.
+func MaskEnvValue(value string) string {
+	if len(value) > 4 {
+		value = value[len(value)-4:]
+	}
+	return "xxxx" + value
+}
+
+func (c *client) ListEnvVars(ctx context.Context, p ProjectSlug) ([]EnvVar, error) {
+	var e []EnvVar
+	err := c.do(ctx, call{
+		op:     "ListEnvVars",
+		method: "GET",
+		path:   p.path("envvar"),
+		params: map[string]string{
+			"project": p.String(),
+		},
+	}, &e)
+	if err != nil {
+		return make([]EnvVar, 0), err
+	}
+
+	return e, nil
+}
+
+func (c *client) GetEnvVar(ctx context.Context, p ProjectSlug, name string) (EnvVar, error) {
+	var e EnvVar
+	err := c.do(ctx, call{
+		op:     "GetEnvVar",
+		method: "GET",
+		path:   p.path("envvar", name),
+		params: map[string]string{
+			"project": p.String(),
+			"name":    name,
+		},
+	}, &e)
+	if err != nil {
+		return EnvVar{}, err
+	}
+
+	return e, nil
+}
+
+func (c *client) SetEnvVar(ctx context.Context, p ProjectSlug, name, value string) (EnvVar, error) {
+	var e EnvVar
+	err := c.do(ctx, call{
+		op:     "SetEnvVar",
+		method: "POST",
+		path:   p.path("envvar"),
+		body:   EnvVar{Name: name, Value: value},
+		params: map[string]string{
+			"project": p.String(),
+			"name":    name,
+		},
+	}, &e)
+	if err != nil {
+		return EnvVar{}, err
+	}
+
+	return e, nil
+}
+
+func (c *client) DeleteEnvVar(ctx context.Context, p ProjectSlug, name string) error {
+	return c.do(ctx, call{
+		op:     "DeleteEnvVar",
+		method: "DELETE",
+		path:   p.path("envvar", name),
+		params: map[string]string{
+			"project": p.String(),
+			"name":    name,
+		},
+	}, nil)
+}
+
+// ParseEnvFile parses environment variables in the format of a .env file.
+// Each line holds a `NAME=value` pair, optionally preceded by `export`. Blank
+// lines and lines starting with `#` are ignored. Values may be wrapped in
+// single quotes, which are taken literally, or double quotes, which may
+// contain Go escape sequences such as `\n`.
+func ParseEnvFile(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("circle: env file line %d: expected NAME=value", line)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("circle: env file line %d: invalid quoted value", line)
+			}
+			value = unquoted
+		}
+		env[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// Options for SyncEnvVars.
+type SyncEnvVarsOptions struct {
+	// Delete environment variables of the project that are not desired.
+	Prune bool
+	// Set every desired environment variable, even those whose masked value
+	// matches the project's. By default they are assumed to be unchanged,
+	// which misses changes that keep the last four characters of a value.
+	Force bool
+	// Compute the changes without applying them.
+	DryRun bool
+}
+
+// Changes made by SyncEnvVars, as names of environment variables in
+// alphabetical order.
+type EnvVarDiff struct {
+	Added     []string
+	Updated   []string
+	Deleted   []string
+	Unchanged []string
+}
+
+// SyncEnvVars makes the environment variables of the project `p` match
+// `desired`, and returns the changes it made. It stops at the first change
+// that fails, in which case the returned diff holds the changes made so far.
+func SyncEnvVars(ctx context.Context, c CircleCI, p ProjectSlug, desired map[string]string, opts SyncEnvVarsOptions) (EnvVarDiff, error) {
+	vars, err := c.ListEnvVars(ctx, p)
+	if err != nil {
+		return EnvVarDiff{}, err
+	}
+	current := make(map[string]string, len(vars))
+	for _, v := range vars {
+		current[v.Name] = v.Value
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diff EnvVarDiff
+	for _, name := range names {
+		value := desired[name]
+		masked, exists := current[name]
+		if exists && !opts.Force && masked == MaskEnvValue(value) {
+			diff.Unchanged = append(diff.Unchanged, name)
+			continue
+		}
+		if !opts.DryRun {
+			if _, err := c.SetEnvVar(ctx, p, name, value); err != nil {
+				return diff, err
+			}
+		}
+		if exists {
+			diff.Updated = append(diff.Updated, name)
+		} else {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+
+	if !opts.Prune {
+		return diff, nil
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	for _, v := range vars {
+		if _, ok := desired[v.Name]; ok {
+			continue
+		}
+		if !opts.DryRun {
+			if err := c.DeleteEnvVar(ctx, p, v.Name); err != nil {
+				return diff, err
+			}
+		}
+		diff.Deleted = append(diff.Deleted, v.Name)
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,226 @@
+package circle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{"empty", "", map[string]string{}},
+		{"plain", "A=1\nB=two words\n", map[string]string{"A": "1", "B": "two words"}},
+		{"comments and blank lines", "# comment\n\n  \nA=1\n  # indented comment\n", map[string]string{"A": "1"}},
+		{"export", "export A=1\nexport  B = 2 \n", map[string]string{"A": "1", "B": "2"}},
+		{"empty value", "A=\nB=''\n", map[string]string{"A": "", "B": ""}},
+		{"equals in value", "URL=https://example.com/?a=b", map[string]string{"URL": "https://example.com/?a=b"}},
+		{"single quotes", `A='a\nb "c" $D'`, map[string]string{"A": `a\nb "c" $D`}},
+		{"double quotes", `A="a\nb \"c\" 'd'"`, map[string]string{"A": "a\nb \"c\" 'd'"}},
+		{"unbalanced quote", `A="abc`, map[string]string{"A": `"abc`}},
+		{"last definition wins", "A=1\nA=2", map[string]string{"A": "2"}},
+		{"CRLF", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEnvFile(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEnvFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		wantLine string
+	}{
+		{"missing equals", "A=1\nB\n", "line 2"},
+		{"missing name", "# x\n=1", "line 2"},
+		{"space in name", "A B=1", "line 1"},
+		{"invalid escape", `A=1` + "\n\n" + `B="\q"`, "line 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := ParseEnvFile(strings.NewReader(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.wantLine) {
+				t.Fatalf("ParseEnvFile() = %v, %v, want an error on %s", env, err, tt.wantLine)
+			}
+			if env != nil {
+				t.Errorf("ParseEnvFile() = %v, want nil", env)
+			}
+		})
+	}
+}
+
+// envVarServer serves the environment variables of gh/org/repo, initially
+// `vars` as names mapped to values, and records the requests that change them
+// in `requests` as "POST NAME=value" and "DELETE NAME". Changes to the
+// variable named `fail` fail.
+func envVarServer(t *testing.T, vars map[string]string, fail string, requests *[]string) *httptest.Server {
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		path := strings.TrimPrefix(r.URL.Path, "/v1.1/project/github/org/repo/envvar")
+		switch {
+		case r.Method == "GET" && path == "":
+			list := make([]EnvVar, 0, len(vars))
+			for name, value := range vars {
+				list = append(list, EnvVar{Name: name, Value: MaskEnvValue(value)})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == "POST" && path == "":
+			var e EnvVar
+			b, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(b, &e); err != nil {
+				t.Errorf("decoding %s: %v", b, err)
+			}
+			*requests = append(*requests, "POST "+e.Name+"="+e.Value)
+			if e.Name == fail {
+				http.Error(w, `{"message":"nope"}`, http.StatusBadRequest)
+				return
+			}
+			vars[e.Name] = e.Value
+			json.NewEncoder(w).Encode(EnvVar{Name: e.Name, Value: MaskEnvValue(e.Value)})
+		case r.Method == "DELETE":
+			name := strings.TrimPrefix(path, "/")
+			*requests = append(*requests, "DELETE "+name)
+			if name == fail {
+				http.Error(w, `{"message":"nope"}`, http.StatusBadRequest)
+				return
+			}
+			delete(vars, name)
+			w.Write([]byte(`{"message":"ok"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSyncEnvVars(t *testing.T) {
+	current := map[string]string{
+		"SAME":    "unchanged-value",
+		"MASKED":  "old-1234",
+		"CHANGED": "old-1111",
+		"EXTRA":   "x",
+		"OTHER":   "y",
+	}
+	desired := map[string]string{
+		"SAME":    "unchanged-value",
+		"MASKED":  "new-1234", // Same masked value.
+		"CHANGED": "new-2222",
+		"NEW":     "n",
+	}
+
+	tests := []struct {
+		name         string
+		opts         SyncEnvVarsOptions
+		fail         string
+		want         EnvVarDiff
+		wantRequests []string
+		wantErr      bool
+	}{
+		{
+			name: "default",
+			want: EnvVarDiff{
+				Added:     []string{"NEW"},
+				Updated:   []string{"CHANGED"},
+				Unchanged: []string{"MASKED", "SAME"},
+			},
+			wantRequests: []string{"POST CHANGED=new-2222", "POST NEW=n"},
+		},
+		{
+			name: "force",
+			opts: SyncEnvVarsOptions{Force: true},
+			want: EnvVarDiff{
+				Added:   []string{"NEW"},
+				Updated: []string{"CHANGED", "MASKED", "SAME"},
+			},
+			wantRequests: []string{"POST CHANGED=new-2222", "POST MASKED=new-1234", "POST NEW=n", "POST SAME=unchanged-value"},
+		},
+		{
+			name: "prune",
+			opts: SyncEnvVarsOptions{Prune: true},
+			want: EnvVarDiff{
+				Added:     []string{"NEW"},
+				Updated:   []string{"CHANGED"},
+				Deleted:   []string{"EXTRA", "OTHER"},
+				Unchanged: []string{"MASKED", "SAME"},
+			},
+			wantRequests: []string{"POST CHANGED=new-2222", "POST NEW=n", "DELETE EXTRA", "DELETE OTHER"},
+		},
+		{
+			name: "dry run",
+			opts: SyncEnvVarsOptions{Prune: true, Force: true, DryRun: true},
+			want: EnvVarDiff{
+				Added:   []string{"NEW"},
+				Updated: []string{"CHANGED", "MASKED", "SAME"},
+				Deleted: []string{"EXTRA", "OTHER"},
+			},
+		},
+		{
+			name: "failed set",
+			opts: SyncEnvVarsOptions{Prune: true},
+			fail: "NEW",
+			want: EnvVarDiff{
+				Updated:   []string{"CHANGED"},
+				Unchanged: []string{"MASKED"},
+			},
+			wantRequests: []string{"POST CHANGED=new-2222", "POST NEW=n"},
+			wantErr:      true,
+		},
+		{
+			name: "failed delete",
+			opts: SyncEnvVarsOptions{Prune: true},
+			fail: "OTHER",
+			want: EnvVarDiff{
+				Added:     []string{"NEW"},
+				Updated:   []string{"CHANGED"},
+				Deleted:   []string{"EXTRA"},
+				Unchanged: []string{"MASKED", "SAME"},
+			},
+			wantRequests: []string{"POST CHANGED=new-2222", "POST NEW=n", "DELETE EXTRA", "DELETE OTHER"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := make(map[string]string, len(current))
+			for name, value := range current {
+				vars[name] = value
+			}
+			var requests []string
+			srv := envVarServer(t, vars, tt.fail, &requests)
+			c := New("token", WithBaseURL(srv.URL))
+
+			diff, err := SyncEnvVars(context.Background(), c, GitHubProject("org", "repo"), desired, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(diff, tt.want) {
+				t.Errorf("diff = %+v, want %+v", diff, tt.want)
+			}
+			if !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("requests = %q, want %q", requests, tt.wantRequests)
+			}
+		})
+	}
+}
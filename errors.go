@@ -0,0 +1,129 @@
+package circle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Sentinel errors matched by an *APIError with the corresponding status code,
+// e.g. `errors.Is(err, circle.ErrNotFound)`.
+var (
+	ErrUnauthorized = errors.New("circle: unauthorized")
+	ErrForbidden    = errors.New("circle: forbidden")
+	ErrNotFound     = errors.New("circle: not found")
+	ErrRateLimited  = errors.New("circle: rate limited")
+)
+
+// maxErrorBodySize bounds how much of an error response is read to find the
+// message.
+const maxErrorBodySize = 64 << 10
+
+// APIError is returned when CircleCI responds with a non-2xx status code.
+type APIError struct {
+	// HTTP status code of the response.
+	StatusCode int
+	// Message reported by CircleCI, if any.
+	Message string
+	// HTTP method of the request.
+	Method string
+	// Path of the request, with any credentials removed.
+	Path string
+}
+
+func (e *APIError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message == "" {
+		return fmt.Sprintf("circle: %s %s: %s", e.Method, e.Path, status)
+	}
+	return fmt.Sprintf("circle: %s %s: %s: %s", e.Method, e.Path, status, e.Message)
+}
+
+// Is reports whether the error matches one of the sentinel errors for its
+// status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// checkResponse returns an *APIError if `response` does not have a 2xx
+// status code, reading the message from `body` in that case.
+func checkResponse(response *http.Response, body io.Reader) error {
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	e := &APIError{
+		StatusCode: response.StatusCode,
+		Method:     response.Request.Method,
+		Path:       redactedPath(response.Request.URL),
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(body, maxErrorBodySize))
+	var m struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(b, &m) == nil {
+		e.Message = m.Message
+	}
+
+	return e
+}
+
+// redactedPath returns the path and query of `u` without credentials passed
+// as query parameters, such as an API token or the signature of a pre-signed
+// URL.
+func redactedPath(u *url.URL) string {
+	query := u.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "token") || strings.Contains(lower, "signature") || strings.Contains(lower, "credential") {
+			query.Del(name)
+		}
+	}
+	if len(query) == 0 {
+		return u.EscapedPath()
+	}
+	return u.EscapedPath() + "?" + query.Encode()
+}
+
+// redacted replaces the API token wherever it would otherwise be printed.
+const redacted = "[REDACTED]"
+
+// redact returns `err` with any occurrence of the API token removed from its
+// message. The original error remains available through errors.Unwrap, except
+// for an *APIError, e.g. one whose message echoes the token, which is
+// redacted in place so that it can still be inspected with errors.As.
+func (c *client) redact(err error) error {
+	if err == nil || c.token == "" || !strings.Contains(err.Error(), c.token) {
+		return err
+	}
+	if e, ok := err.(*APIError); ok {
+		e.Message = strings.ReplaceAll(e.Message, c.token, redacted)
+		e.Path = strings.ReplaceAll(e.Path, c.token, redacted)
+		return e
+	}
+	return &redactedError{strings.ReplaceAll(err.Error(), c.token, redacted), err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,147 @@
+package circle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenNeverRevealed(t *testing.T) {
+	const token = "SENTINEL-0123456789abcdef"
+	var log bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	retry := RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}
+	ctx := context.Background()
+
+	check := func(what, s string) {
+		t.Helper()
+		if strings.Contains(s, token) {
+			t.Errorf("%s reveals the token: %s", what, s)
+		}
+	}
+
+	// A server that echoes the token in its error messages.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ := r.BasicAuth()
+		if user != token {
+			t.Errorf("request authenticated as %q", user)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"message":"invalid token %s"}`, user)
+	}))
+	defer srv.Close()
+	c := New(token, WithBaseURL(srv.URL), WithLogger(logger), WithRetryPolicy(retry))
+
+	_, err := c.MeContext(ctx)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want an *APIError matching ErrUnauthorized", err)
+	}
+	check("APIError.Error()", err.Error())
+	check("APIError.Message", apiErr.Message)
+	_, err = c.DownloadArtifactRange(ctx, Artifact{URL: srv.URL + "/artifact"}, 0)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	check("streamed APIError.Error()", err.Error())
+
+	// Connection errors, including those of middleware that mention the
+	// credentials of the request.
+	srv.Close()
+	_, err = c.MeContext(ctx)
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	check("connection error", err.Error())
+	echo := func(next Handler) Handler {
+		return func(r *http.Request) (*http.Response, error) {
+			user, _, _ := r.BasicAuth()
+			return nil, fmt.Errorf("proxy rejected credentials %q", user)
+		}
+	}
+	c = New(token, WithMiddleware(echo), WithLogger(logger), WithRetryPolicy(retry))
+	_, err = c.MeContext(ctx)
+	if err == nil || !strings.Contains(err.Error(), redacted) {
+		t.Fatalf("err = %v, want the redacted credentials", err)
+	}
+	check("middleware error", err.Error())
+	check("%+v of the middleware error", fmt.Sprintf("%+v", err))
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		check(format+" of the client", fmt.Sprintf(format, c))
+	}
+	var jsonLog bytes.Buffer
+	slog.New(slog.NewJSONHandler(&jsonLog, nil)).Info("client", "client", c)
+	logger.Info("client", "client", c)
+	check("JSON log", jsonLog.String())
+
+	if !strings.Contains(log.String(), "circle: retrying call") || !strings.Contains(log.String(), "circle: call failed") {
+		t.Errorf("failed calls not logged:\n%s", log.String())
+	}
+	check("log", log.String())
+}
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		w.WriteHeader(status)
+		w.Write([]byte(r.URL.Query().Get("body")))
+	}))
+	defer srv.Close()
+	c := New("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1})).(*client)
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited}
+
+	tests := []struct {
+		status      int
+		contentType string
+		body        string
+		sentinel    error
+		wantMessage string
+		wantError   string
+	}{
+		{401, "application/json", `{"message":"You must log in first."}`, ErrUnauthorized, "You must log in first.", ": 401 Unauthorized: You must log in first."},
+		{403, "application/json", `{"message":"Permission denied"}`, ErrForbidden, "Permission denied", ": 403 Forbidden: Permission denied"},
+		{404, "application/json", `{"message":"Project not found"}`, ErrNotFound, "Project not found", ": 404 Not Found: Project not found"},
+		{429, "application/json", `{"message":"Slow down"}`, ErrRateLimited, "Slow down", ": 429 Too Many Requests: Slow down"},
+		{500, "application/json", `{"message":"Oops"}`, nil, "Oops", ": 500 Internal Server Error: Oops"},
+		{502, "text/html", `<html><body>Bad Gateway</body></html>`, nil, "", ": 502 Bad Gateway"},
+		{404, "application/json", `{"error":"not a message"}`, ErrNotFound, "", ": 404 Not Found"},
+		{400, "text/plain", ``, nil, "", ": 400 Bad Request"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d %s", tt.status, tt.body), func(t *testing.T) {
+			query := url.Values{"status": {strconv.Itoa(tt.status)}, "type": {tt.contentType}, "body": {tt.body}}
+			err := c.do(context.Background(), call{op: "Test", method: "GET", path: "/test", query: query}, new(interface{}))
+
+			var e *APIError
+			if !errors.As(err, &e) {
+				t.Fatalf("err = %v, want an *APIError", err)
+			}
+			if e.StatusCode != tt.status || e.Message != tt.wantMessage || e.Method != "GET" {
+				t.Errorf("APIError = %+v", e)
+			}
+			if !strings.HasPrefix(e.Path, "/v1.1/test?") {
+				t.Errorf("Path = %q", e.Path)
+			}
+			if want := "circle: GET " + e.Path + tt.wantError; err.Error() != want {
+				t.Errorf("Error() = %q, want %q", err.Error(), want)
+			}
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.sentinel) {
+					t.Errorf("errors.Is(err, %v) = %v", sentinel, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package circle
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Response type indicating whether a project is followed.
+type FollowResponse struct {
+	Following bool `json:"following"`
+	// The build triggered by following a project for the first time, if any.
+	FirstBuild *Build `json:"first_build"`
+}
+
+func (c *client) FollowProject(ctx context.Context, p ProjectSlug) (FollowResponse, error) {
+	return c.follow(ctx, "FollowProject", p, "follow")
+}
+
+func (c *client) UnfollowProject(ctx context.Context, p ProjectSlug) (FollowResponse, error) {
+	return c.follow(ctx, "UnfollowProject", p, "unfollow")
+}
+
+func (c *client) follow(ctx context.Context, op string, p ProjectSlug, action string) (FollowResponse, error) {
+	var f FollowResponse
+	err := c.do(ctx, call{
+		op:     op,
+		method: "POST",
+		path:   p.path(action),
+		params: map[string]string{
+			"project": p.String(),
+		},
+	}, &f)
+	if err != nil {
+		return FollowResponse{}, err
+	}
+
+	return f, nil
+}
+
+func (c *client) EnableProject(ctx context.Context, p ProjectSlug) (Project, error) {
+	var project Project
+	err := c.do(ctx, call{
+		op:     "EnableProject",
+		method: "POST",
+		path:   p.path("enable"),
+		params: map[string]string{
+			"project": p.String(),
+		},
+	}, &project)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+// Options for ReconcileFollowed.
+type ReconcileFollowedOptions struct {
+	// Compute the changes without applying them.
+	DryRun bool
+}
+
+// Changes made by ReconcileFollowed, as projects in alphabetical order.
+type FollowDiff struct {
+	Followed   []ProjectSlug
+	Unfollowed []ProjectSlug
+	Unchanged  []ProjectSlug
+	// The VCS URLs of followed projects whose slug could not be parsed, and
+	// that were left alone.
+	Unparsed []string
+}
+
+// ReconcileFollowed makes the projects followed by the authenticated user
+// those in `desired`, and returns the changes it made. Projects are compared
+// ignoring case, as GitHub and Bitbucket do. Followed projects whose VCS URL
+// cannot be parsed are reported in FollowDiff.Unparsed rather than unfollowed.
+// It stops at the first change that fails, in which case the returned diff
+// holds the changes made so far.
+func ReconcileFollowed(ctx context.Context, c CircleCI, desired []ProjectSlug, opts ReconcileFollowedOptions) (FollowDiff, error) {
+	projects, err := c.ProjectsContext(ctx)
+	if err != nil {
+		return FollowDiff{}, err
+	}
+
+	var diff FollowDiff
+	followed := make(map[string]ProjectSlug)
+	for _, project := range projects {
+		if !project.Followed {
+			continue
+		}
+		p, err := project.Slug()
+		if err != nil {
+			diff.Unparsed = append(diff.Unparsed, project.VCSURL)
+			continue
+		}
+		followed[followKey(p)] = p
+	}
+	sort.Strings(diff.Unparsed)
+
+	wanted := make(map[string]ProjectSlug, len(desired))
+	for _, p := range desired {
+		wanted[followKey(p)] = p
+	}
+
+	for _, key := range sortedKeys(wanted) {
+		p := wanted[key]
+		if _, ok := followed[key]; ok {
+			diff.Unchanged = append(diff.Unchanged, p)
+			continue
+		}
+		if !opts.DryRun {
+			if _, err := c.FollowProject(ctx, p); err != nil {
+				return diff, err
+			}
+		}
+		diff.Followed = append(diff.Followed, p)
+	}
+
+	for _, key := range sortedKeys(followed) {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		p := followed[key]
+		if !opts.DryRun {
+			if _, err := c.UnfollowProject(ctx, p); err != nil {
+				return diff, err
+			}
+		}
+		diff.Unfollowed = append(diff.Unfollowed, p)
+	}
+
+	return diff, nil
+}
+
+// followKey returns the key identifying `p` in ReconcileFollowed.
+func followKey(p ProjectSlug) string {
+	return strings.ToLower(p.String())
+}
+
+// sortedKeys returns the keys of `m` in alphabetical order.
+func sortedKeys(m map[string]ProjectSlug) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
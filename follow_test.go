@@ -0,0 +1,87 @@
+package circle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// followServer lists `projects`, as VCS URLs mapped to whether they are
+// followed, and records the follow and unfollow requests it receives as
+// "follow github/org/repo" in `changes`.
+func followServer(t *testing.T, projects map[string]bool, changes *[]string) *httptest.Server {
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1.1/projects" {
+			var list []string
+			for url, followed := range projects {
+				list = append(list, fmt.Sprintf(`{"vcs_url":%q,"followed":%v}`, url, followed))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(list, ","))
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1.1/project/")
+		i := strings.LastIndex(path, "/")
+		mu.Lock()
+		*changes = append(*changes, path[i+1:]+" "+path[:i])
+		mu.Unlock()
+		w.Write([]byte(`{"following":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReconcileFollowed(t *testing.T) {
+	projects := map[string]bool{
+		"https://github.com/org/kept":         true,
+		"https://github.com/org/dropped":      true,
+		"https://bitbucket.org/org/Cased":     true,
+		"https://github.com/org/not-followed": false,
+		"https://github.com/org":              true,
+		"not a url":                           true,
+	}
+	desired := []ProjectSlug{
+		GitHubProject("org", "kept"),
+		GitHubProject("org", "added"),
+		{VCSType: VCSBitbucket, Org: "org", Repo: "cased"},
+	}
+	want := FollowDiff{
+		Followed:   []ProjectSlug{GitHubProject("org", "added")},
+		Unfollowed: []ProjectSlug{GitHubProject("org", "dropped")},
+		Unchanged: []ProjectSlug{
+			{VCSType: VCSBitbucket, Org: "org", Repo: "cased"},
+			GitHubProject("org", "kept"),
+		},
+		Unparsed: []string{"https://github.com/org", "not a url"},
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		t.Run(fmt.Sprintf("DryRun=%v", dryRun), func(t *testing.T) {
+			var changes []string
+			srv := followServer(t, projects, &changes)
+			c := New("token", WithBaseURL(srv.URL))
+
+			diff, err := ReconcileFollowed(context.Background(), c, desired, ReconcileFollowedOptions{DryRun: dryRun})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diff, want) {
+				t.Errorf("diff = %+v, want %+v", diff, want)
+			}
+
+			var wantChanges []string
+			if !dryRun {
+				wantChanges = []string{"follow github/org/added", "unfollow github/org/dropped"}
+			}
+			if !reflect.DeepEqual(changes, wantChanges) {
+				t.Errorf("changes = %q, want %q", changes, wantChanges)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package circle
+
+import (
+	"context"
+)
+
+// Type of a CheckoutKey.
+type CheckoutKeyType string
+
+const (
+	// A key with read access to a single repository.
+	CheckoutKeyDeploy CheckoutKeyType = "deploy-key"
+	// A key with the access of the user who created it, which is needed to
+	// check out other private repositories during the build.
+	CheckoutKeyUser CheckoutKeyType = "github-user-key"
+)
+
+// A key CircleCI uses to check out the code of a project.
+type CheckoutKey struct {
+	PublicKey   string          `json:"public_key"`
+	Type        CheckoutKeyType `json:"type"`
+	Fingerprint string          `json:"fingerprint"`
+	Preferred   bool            `json:"preferred"`
+	Time        Time            `json:"time"`
+}
+
+func (c *client) ListCheckoutKeys(ctx context.Context, p ProjectSlug) ([]CheckoutKey, error) {
+	var k []CheckoutKey
+	err := c.do(ctx, call{
+		op:     "ListCheckoutKeys",
+		method: "GET",
+		path:   p.path("checkout-key"),
+		params: map[string]string{
+			"project": p.String(),
+		},
+	}, &k)
+	if err != nil {
+		return make([]CheckoutKey, 0), err
+	}
+
+	return k, nil
+}
+
+func (c *client) CreateCheckoutKey(ctx context.Context, p ProjectSlug, t CheckoutKeyType) (CheckoutKey, error) {
+	var k CheckoutKey
+	err := c.do(ctx, call{
+		op:     "CreateCheckoutKey",
+		method: "POST",
+		path:   p.path("checkout-key"),
+		body: struct {
+			Type CheckoutKeyType `json:"type"`
+		}{t},
+		params: map[string]string{
+			"project": p.String(),
+			"type":    string(t),
+		},
+	}, &k)
+	if err != nil {
+		return CheckoutKey{}, err
+	}
+
+	return k, nil
+}
+
+func (c *client) GetCheckoutKey(ctx context.Context, p ProjectSlug, fingerprint string) (CheckoutKey, error) {
+	var k CheckoutKey
+	err := c.do(ctx, call{
+		op:     "GetCheckoutKey",
+		method: "GET",
+		path:   p.path("checkout-key", fingerprint),
+		params: map[string]string{
+			"project":     p.String(),
+			"fingerprint": fingerprint,
+		},
+	}, &k)
+	if err != nil {
+		return CheckoutKey{}, err
+	}
+
+	return k, nil
+}
+
+func (c *client) DeleteCheckoutKey(ctx context.Context, p ProjectSlug, fingerprint string) error {
+	return c.do(ctx, call{
+		op:     "DeleteCheckoutKey",
+		method: "DELETE",
+		path:   p.path("checkout-key", fingerprint),
+		params: map[string]string{
+			"project":     p.String(),
+			"fingerprint": fingerprint,
+		},
+	}, nil)
+}
+
+func (c *client) AddSSHKey(ctx context.Context, p ProjectSlug, hostname, privateKey string) error {
+	return c.do(ctx, call{
+		op:     "AddSSHKey",
+		method: "POST",
+		path:   p.path("ssh-key"),
+		body: struct {
+			Hostname   string `json:"hostname"`
+			PrivateKey string `json:"private_key"`
+		}{hostname, privateKey},
+		params: map[string]string{
+			"project":  p.String(),
+			"hostname": hostname,
+		},
+	}, nil)
+}
+
+func (c *client) RemoveSSHKey(ctx context.Context, p ProjectSlug, hostname, fingerprint string) error {
+	return c.do(ctx, call{
+		op:     "RemoveSSHKey",
+		method: "DELETE",
+		path:   p.path("ssh-key"),
+		body: struct {
+			Hostname    string `json:"hostname"`
+			Fingerprint string `json:"fingerprint"`
+		}{hostname, fingerprint},
+		params: map[string]string{
+			"project":     p.String(),
+			"hostname":    hostname,
+			"fingerprint": fingerprint,
+		},
+	}, nil)
+}
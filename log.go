@@ -0,0 +1,108 @@
+package circle
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithLogger logs every call made by the client to `logger`: completed calls
+// at info level, failed calls at warn level and retried attempts at debug
+// level. The API token and request bodies are never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *client) {
+		c.logger = logger
+	}
+}
+
+// logCall logs the outcome of `call`.
+func (c *client) logCall(ctx context.Context, call call, s stats, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", call.op),
+		slog.String("method", call.method),
+		slog.String("path", s.path),
+		slog.Int("status", s.status),
+		slog.Duration("latency", latency),
+		slog.Int("attempts", s.attempts),
+		slog.Int64("bytes", s.bytes),
+	}
+	if len(call.params) > 0 {
+		attrs = append(attrs, slog.Any("params", call.params))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "circle: call failed", attrs...)
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "circle: call completed", attrs...)
+}
+
+// logRetry logs an attempt of `call` that is about to be retried after
+// `wait`.
+func (c *client) logRetry(ctx context.Context, call call, attempt int, response *http.Response, err error, wait time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", call.op),
+		slog.Int("attempt", attempt),
+		slog.Duration("wait", wait),
+	}
+	if response != nil {
+		attrs = append(attrs, slog.Int("status", response.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "circle: retrying call", attrs...)
+}
+
+// LogValue implements slog.LogValuer, redacting the Heroku API key.
+func (m Me) LogValue() slog.Value {
+	type plain Me
+	p := plain(m)
+	if p.HerokuAPIKey != nil {
+		p.HerokuAPIKey = redactedString()
+	}
+	return slog.AnyValue(p)
+}
+
+// LogValue implements slog.LogValuer, redacting AWS credentials and the
+// webhooks, tokens and passwords of notification integrations.
+func (p Project) LogValue() slog.Value {
+	type plain Project
+	q := plain(p)
+	if q.SlackWebhookURL != "" {
+		q.SlackWebhookURL = redacted
+	}
+	if q.AWS.KeyPair != nil {
+		keyPair := *q.AWS.KeyPair
+		keyPair.SecretAccessKey = redacted
+		q.AWS.KeyPair = &keyPair
+	}
+	for _, secret := range []**string{
+		&q.CampfireToken,
+		&q.FlowdockAPIToken,
+		&q.HipChatAPIToken,
+		&q.IRCPassword,
+		&q.SlackAPIToken,
+	} {
+		if *secret != nil {
+			*secret = redactedString()
+		}
+	}
+	return slog.AnyValue(q)
+}
+
+// redactedString returns a pointer to a copy of redacted.
+func redactedString() *string {
+	s := redacted
+	return &s
+}
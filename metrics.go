@@ -0,0 +1,197 @@
+package circle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the latency histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects statistics about the calls made by clients, labelled by
+// operation (e.g. "BuildSummary"). It is safe for concurrent use and may be
+// shared by several clients.
+//
+// Metrics implements expvar.Var, so it can be published with
+// `expvar.Publish("circle", metrics)`, and Handler serves it in the
+// Prometheus text format.
+type Metrics struct {
+	mu  sync.Mutex
+	ops map[string]*operationMetrics
+}
+
+type operationMetrics struct {
+	calls int64
+	// Failed calls keyed by status code, or 0 if there was no response.
+	errors map[int]int64
+	bytes  int64
+	// Non-cumulative counts per latency bucket, with a final +Inf bucket.
+	buckets    []int64
+	latencySum float64
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{ops: make(map[string]*operationMetrics)}
+}
+
+// WithMetrics records statistics about every call made by the client in `m`.
+func WithMetrics(m *Metrics) Option {
+	return func(c *client) {
+		c.metrics = m
+	}
+}
+
+// observe records a call of `op`.
+func (m *Metrics) observe(op string, s stats, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.ops[op]
+	if !ok {
+		o = &operationMetrics{
+			errors:  make(map[int]int64),
+			buckets: make([]int64, len(latencyBuckets)+1),
+		}
+		m.ops[op] = o
+	}
+
+	o.calls++
+	if err != nil {
+		o.errors[s.status]++
+	}
+	o.bytes += s.bytes
+	seconds := latency.Seconds()
+	o.latencySum += seconds
+	o.buckets[sort.SearchFloat64s(latencyBuckets, seconds)]++
+}
+
+// operations returns the names of the operations seen so far, sorted.
+func (m *Metrics) operations() []string {
+	names := make([]string, 0, len(m.ops))
+	for name := range m.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the metrics as a JSON object keyed by operation, for expvar.
+func (m *Metrics) String() string {
+	type latency struct {
+		Sum     float64          `json:"sum"`
+		Count   int64            `json:"count"`
+		Buckets map[string]int64 `json:"buckets"`
+	}
+	type operation struct {
+		Calls   int64            `json:"calls"`
+		Errors  map[string]int64 `json:"errors"`
+		Bytes   int64            `json:"bytes"`
+		Latency latency          `json:"latency_seconds"`
+	}
+
+	m.mu.Lock()
+	out := make(map[string]operation, len(m.ops))
+	for name, o := range m.ops {
+		op := operation{
+			Calls:   o.calls,
+			Errors:  make(map[string]int64, len(o.errors)),
+			Bytes:   o.bytes,
+			Latency: latency{Sum: o.latencySum, Count: o.calls, Buckets: make(map[string]int64)},
+		}
+		for status, n := range o.errors {
+			op.Errors[statusLabel(status)] = n
+		}
+		var cumulative int64
+		for i, n := range o.buckets {
+			cumulative += n
+			op.Latency.Buckets[bucketLabel(i)] = cumulative
+		}
+		out[name] = op
+	}
+	m.mu.Unlock()
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// Handler returns a handler serving the metrics in the Prometheus text
+// exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		m.writePrometheus(&buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+func (m *Metrics) writePrometheus(buf *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := m.operations()
+
+	buf.WriteString("# HELP circle_api_calls_total Calls made to the CircleCI API.\n")
+	buf.WriteString("# TYPE circle_api_calls_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(buf, "circle_api_calls_total{operation=%q} %d\n", name, m.ops[name].calls)
+	}
+
+	buf.WriteString("# HELP circle_api_errors_total Failed calls to the CircleCI API by status code.\n")
+	buf.WriteString("# TYPE circle_api_errors_total counter\n")
+	for _, name := range names {
+		o := m.ops[name]
+		statuses := make([]int, 0, len(o.errors))
+		for status := range o.errors {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(buf, "circle_api_errors_total{operation=%q,status=%q} %d\n", name, statusLabel(status), o.errors[status])
+		}
+	}
+
+	buf.WriteString("# HELP circle_api_response_bytes_total Response body bytes received from the CircleCI API.\n")
+	buf.WriteString("# TYPE circle_api_response_bytes_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(buf, "circle_api_response_bytes_total{operation=%q} %d\n", name, m.ops[name].bytes)
+	}
+
+	buf.WriteString("# HELP circle_api_call_duration_seconds Latency of calls to the CircleCI API, including retries.\n")
+	buf.WriteString("# TYPE circle_api_call_duration_seconds histogram\n")
+	for _, name := range names {
+		o := m.ops[name]
+		var cumulative int64
+		for i, n := range o.buckets {
+			cumulative += n
+			fmt.Fprintf(buf, "circle_api_call_duration_seconds_bucket{operation=%q,le=%q} %d\n", name, bucketLabel(i), cumulative)
+		}
+		fmt.Fprintf(buf, "circle_api_call_duration_seconds_sum{operation=%q} %s\n", name, strconv.FormatFloat(o.latencySum, 'g', -1, 64))
+		fmt.Fprintf(buf, "circle_api_call_duration_seconds_count{operation=%q} %d\n", name, o.calls)
+	}
+}
+
+// statusLabel formats a status code for use as a label.
+func statusLabel(status int) string {
+	if status == 0 {
+		return "none"
+	}
+	return strconv.Itoa(status)
+}
+
+// bucketLabel formats the upper bound of the i-th latency bucket.
+func bucketLabel(i int) string {
+	if i == len(latencyBuckets) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(latencyBuckets[i], 'g', -1, 64)
+}
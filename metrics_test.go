@@ -0,0 +1,152 @@
+package circle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/404") {
+			time.Sleep(30 * time.Millisecond)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Build not found"}`))
+			return
+		}
+		w.Write([]byte(`{"build_num":1}`))
+	}))
+	defer srv.Close()
+
+	m := NewMetrics()
+	c := New("token", WithBaseURL(srv.URL), WithMetrics(m))
+	ctx := context.Background()
+	p := GitHubProject("org", "repo")
+	if _, err := c.GetBuild(ctx, p, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetBuild(ctx, p, 404); err == nil {
+		t.Fatal("expected an error")
+	}
+	// A client without a server to talk to, sharing the metrics.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	noRetry := RetryPolicy{MaxAttempts: 1}
+	if _, err := New("token", WithBaseURL(closed.URL), WithMetrics(m), WithRetryPolicy(noRetry)).MeContext(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	t.Run("expvar", func(t *testing.T) {
+		var ops map[string]struct {
+			Calls   int64            `json:"calls"`
+			Errors  map[string]int64 `json:"errors"`
+			Bytes   int64            `json:"bytes"`
+			Latency struct {
+				Sum     float64          `json:"sum"`
+				Count   int64            `json:"count"`
+				Buckets map[string]int64 `json:"buckets"`
+			} `json:"latency_seconds"`
+		}
+		if err := json.Unmarshal([]byte(m.String()), &ops); err != nil {
+			t.Fatalf("String() is not JSON: %v\n%s", err, m.String())
+		}
+
+		o := ops["GetBuild"]
+		if o.Calls != 2 || len(o.Errors) != 1 || o.Errors["404"] != 1 {
+			t.Errorf("calls, errors = %d, %v, want 2, map[404:1]", o.Calls, o.Errors)
+		}
+		if want := int64(len(`{"build_num":1}`) + len(`{"message":"Build not found"}`)); o.Bytes != want {
+			t.Errorf("bytes = %d, want %d", o.Bytes, want)
+		}
+		if o.Latency.Count != 2 || o.Latency.Sum < 0.03 {
+			t.Errorf("latency count, sum = %d, %v", o.Latency.Count, o.Latency.Sum)
+		}
+		var previous int64
+		for i := range latencyBuckets {
+			n := o.Latency.Buckets[bucketLabel(i)]
+			if n < previous {
+				t.Errorf("bucket %s = %d, less than the previous one", bucketLabel(i), n)
+			}
+			previous = n
+		}
+		if o.Latency.Buckets["0.025"] > 1 || o.Latency.Buckets["+Inf"] != 2 {
+			t.Errorf("buckets = %v", o.Latency.Buckets)
+		}
+
+		if me := ops["Me"]; me.Calls != 1 || me.Errors["none"] != 1 {
+			t.Errorf("Me calls, errors = %d, %v, want 1, map[none:1]", me.Calls, me.Errors)
+		}
+	})
+
+	t.Run("prometheus", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(w.Body)
+
+		samples := make(map[string]float64)
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			i := strings.LastIndex(line, " ")
+			value, err := strconv.ParseFloat(line[i+1:], 64)
+			if err != nil {
+				t.Fatalf("invalid sample %q", line)
+			}
+			samples[line[:i]] = value
+		}
+
+		for _, want := range []string{
+			"# TYPE circle_api_calls_total counter",
+			"# TYPE circle_api_errors_total counter",
+			"# TYPE circle_api_response_bytes_total counter",
+			"# TYPE circle_api_call_duration_seconds histogram",
+		} {
+			if !strings.Contains(string(body), want+"\n") {
+				t.Errorf("exposition lacks %q", want)
+			}
+		}
+
+		want := map[string]float64{
+			`circle_api_calls_total{operation="GetBuild"}`:                            2,
+			`circle_api_calls_total{operation="Me"}`:                                  1,
+			`circle_api_errors_total{operation="GetBuild",status="404"}`:              1,
+			`circle_api_errors_total{operation="Me",status="none"}`:                   1,
+			`circle_api_call_duration_seconds_count{operation="GetBuild"}`:            2,
+			`circle_api_call_duration_seconds_bucket{operation="GetBuild",le="+Inf"}`: 2,
+			`circle_api_call_duration_seconds_bucket{operation="Me",le="+Inf"}`:       1,
+		}
+		for name, value := range want {
+			if got, ok := samples[name]; !ok || got != value {
+				t.Errorf("%s = %v, want %v", name, got, value)
+			}
+		}
+
+		var previous float64
+		for i := range latencyBuckets {
+			name := `circle_api_call_duration_seconds_bucket{operation="GetBuild",le="` + bucketLabel(i) + `"}`
+			n, ok := samples[name]
+			if !ok || n < previous {
+				t.Errorf("%s = %v, want at least %v", name, n, previous)
+			}
+			previous = n
+		}
+		// The 404 took at least 30ms, so it is not in the 25ms bucket.
+		if n := samples[`circle_api_call_duration_seconds_bucket{operation="GetBuild",le="0.025"}`]; n > 1 {
+			t.Errorf("25ms bucket = %v, want at most 1", n)
+		}
+		if samples[`circle_api_call_duration_seconds_sum{operation="GetBuild"}`] < 0.03 {
+			t.Errorf("latency sum = %v, want at least 0.03", samples[`circle_api_call_duration_seconds_sum{operation="GetBuild"}`])
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package circle
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation identifies the CircleCI method a request is made for.
+type Operation struct {
+	// Name of the method, e.g. "BuildSummary".
+	Name string
+	// Arguments the method was called with, keyed by parameter name, e.g.
+	// "num" and "branch". Methods taking a ProjectSlug identify the project by
+	// "project", in its short form such as "gh/org/repo". The original
+	// methods, which take a GitHub username and project name, use "username"
+	// and "project" instead.
+	Params map[string]string
+}
+
+type operationKey struct{}
+
+// OperationFromContext returns the operation a request made by the client
+// belongs to, given the request's context.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+// Handler performs the round trip of a request.
+type Handler func(*http.Request) (*http.Response, error)
+
+// Middleware wraps the round trip of every request made by the client,
+// including each attempt of a retried call. It may modify the request, act on
+// the response, or answer the request without calling `next`. The operation a
+// request belongs to is available from OperationFromContext.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middleware to the client. The first middleware is the
+// outermost, i.e. it sees requests first and responses last. It may be
+// applied multiple times.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// handler returns the client's HTTP round trip wrapped in its middleware.
+func (c *client) handler() Handler {
+	h := Handler(c.http.Do)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
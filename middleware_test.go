@@ -0,0 +1,62 @@
+package circle
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMiddlewareOperation(t *testing.T) {
+	var got Operation
+	record := func(next Handler) Handler {
+		return func(r *http.Request) (*http.Response, error) {
+			got, _ = OperationFromContext(r.Context())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{}`))),
+			}, nil
+		}
+	}
+	c := New("token", WithMiddleware(record))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func()
+		want Operation
+	}{
+		{
+			"legacy",
+			func() { c.BuildSummaryContext(ctx, "org", "repo", 12) },
+			Operation{"BuildSummary", map[string]string{"username": "org", "project": "repo", "num": "12"}},
+		},
+		{
+			"legacy branch",
+			func() { c.RecentBuildsForProjectBranchContext(ctx, "org", "repo", "main", RecentBuildsOptions{}) },
+			Operation{"RecentBuildsForProjectBranch", map[string]string{"username": "org", "project": "repo", "branch": "main"}},
+		},
+		{
+			"slug",
+			func() { c.GetBuild(ctx, BitbucketProject("org", "repo"), 12) },
+			Operation{"GetBuild", map[string]string{"project": "bb/org/repo", "num": "12"}},
+		},
+		{
+			"no params",
+			func() { c.MeContext(ctx) },
+			Operation{"Me", nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Operation{}
+			tt.call()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("operation = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package circle
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultBaseURL    = "https://circleci.com/api"
+	defaultAPIVersion = "v1.1"
+)
+
+// Option configures a client created by New.
+type Option func(*client)
+
+// WithBaseURL sets the base URL of the CircleCI API, e.g.
+// `https://circleci.example.com/api` for a CircleCI Server install. The API
+// version is appended to it. Defaults to `https://circleci.com/api`.
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithAPIVersion sets the API version requests are made against. Defaults to
+// `v1.1`, the first version to support the `/project/:vcs-type/:org/:repo`
+// routes.
+func WithAPIVersion(version string) Option {
+	return func(c *client) {
+		c.apiVersion = strings.Trim(version, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *client) {
+		c.http = h
+	}
+}
+
+// WithTransport sets the transport used to make requests. It replaces the
+// transport of the HTTP client set by WithHTTPClient, so it should be applied
+// after it.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *client) {
+		h := *c.http
+		h.Transport = rt
+		c.http = &h
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeader adds a header that is sent with every request. It may be
+// applied multiple times.
+func WithHeader(key, value string) Option {
+	return func(c *client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithMaxResponseSize limits the size of response bodies the client will
+// read. Larger responses fail with ErrResponseTooLarge. Defaults to 32MiB.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *client) {
+		c.maxResponseSize = n
+	}
+}
@@ -0,0 +1,122 @@
+package circle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoFailedStep is returned by FailedStepLog for builds without a failed
+// step.
+var ErrNoFailedStep = errors.New("circle: build has no failed step")
+
+// Stream an OutputMessage was written to, as reported in OutputMessage.Type.
+type OutputType string
+
+// Output streams reported by CircleCI.
+const (
+	OutputStdout OutputType = "out"
+	OutputStderr OutputType = "err"
+)
+
+// OutputMessage is a chunk of the output of an action.
+type OutputMessage struct {
+	// Stream the message was written to, OutputStdout or OutputStderr.
+	Type    OutputType `json:"type"`
+	Time    Time       `json:"time"`
+	Message string     `json:"message"`
+}
+
+// IsStderr reports whether the message was written to standard error.
+func (m OutputMessage) IsStderr() bool {
+	return m.Type == OutputStderr
+}
+
+// JoinOutput concatenates the output `messages` into a single log.
+func JoinOutput(messages []OutputMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Message)
+	}
+	return b.String()
+}
+
+// IsFailed reports whether the action failed, timed out or was stopped by an
+// infrastructure failure.
+func (a Action) IsFailed() bool {
+	if a.Failed != nil && *a.Failed {
+		return true
+	}
+	switch a.Status {
+	case StatusFailed, StatusTimedout, StatusInfrastructureFail:
+		return true
+	}
+	return false
+}
+
+func (c *client) ActionOutput(ctx context.Context, p ProjectSlug, num int, a Action) ([]OutputMessage, error) {
+	call := call{
+		op:     "ActionOutput",
+		method: "GET",
+		path:   p.path(strconv.Itoa(num), "output", strconv.Itoa(a.Step), strconv.Itoa(a.Index)),
+		params: map[string]string{
+			"project": p.String(),
+			"num":     strconv.Itoa(num),
+			"step":    strconv.Itoa(a.Step),
+			"index":   strconv.Itoa(a.Index),
+		},
+	}
+	if a.OutputURL != "" {
+		// Output is served from a pre-signed URL, which must not be sent the
+		// API token.
+		call.url = a.OutputURL
+		call.anonymous = true
+	}
+
+	var m []OutputMessage
+	err := c.do(ctx, call, &m)
+	if err != nil {
+		return make([]OutputMessage, 0), err
+	}
+
+	return m, nil
+}
+
+// FailedStepLog fetches the log of the first failed step of the build `b`
+// with `c`, combining the output of every container it failed on. It returns
+// ErrNoFailedStep if no step failed.
+func FailedStepLog(ctx context.Context, c CircleCI, b DetailedBuildSummary) (string, error) {
+	p, err := b.Slug()
+	if err != nil {
+		return "", err
+	}
+
+	for _, step := range b.Steps {
+		var failed []Action
+		for _, a := range step.Actions {
+			if a.IsFailed() {
+				failed = append(failed, a)
+			}
+		}
+		if len(failed) == 0 {
+			continue
+		}
+
+		var log strings.Builder
+		for _, a := range failed {
+			messages, err := c.ActionOutput(ctx, p, b.BuildNum, a)
+			if err != nil {
+				return "", err
+			}
+			if len(failed) > 1 {
+				fmt.Fprintf(&log, "==> %s (container %d)\n", step.Name, a.Index)
+			}
+			log.WriteString(JoinOutput(messages))
+		}
+		return log.String(), nil
+	}
+
+	return "", ErrNoFailedStep
+}
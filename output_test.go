@@ -0,0 +1,56 @@
+package circle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailedStepLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok && r.URL.Path == "/signed" {
+			t.Error("token sent to the pre-signed output URL")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/signed":
+			fmt.Fprint(w, `[{"type":"out","time":"2015-10-30T12:00:00.000Z","message":"container 0\n"}]`)
+		case "/v1.1/project/github/org/repo/12/output/1/1":
+			fmt.Fprint(w, `[{"type":"out","message":"container 1\n"},{"type":"err","message":"boom\n"}]`)
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := New("token", WithBaseURL(srv.URL))
+
+	failed := true
+	b := DetailedBuildSummary{
+		BuildSummary: BuildSummary{BuildNum: 12, VCSURL: "https://github.com/org/repo"},
+		Steps: []Step{
+			{Name: "checkout", Actions: []Action{{Step: 0, Status: StatusSuccess}}},
+			{Name: "make test", Actions: []Action{
+				{Step: 1, Index: 0, Failed: &failed, OutputURL: srv.URL + "/signed"},
+				{Step: 1, Index: 1, Status: StatusTimedout},
+			}},
+		},
+	}
+
+	log, err := FailedStepLog(context.Background(), c, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "==> make test (container 0)\ncontainer 0\n==> make test (container 1)\ncontainer 1\nboom\n"
+	if log != want {
+		t.Errorf("log = %q, want %q", log, want)
+	}
+
+	b.Steps = b.Steps[:1]
+	if _, err := FailedStepLog(context.Background(), c, b); !errors.Is(err, ErrNoFailedStep) {
+		t.Errorf("err = %v, want ErrNoFailedStep", err)
+	}
+}
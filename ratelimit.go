@@ -0,0 +1,141 @@
+package circle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the client to `rps` requests per second on average,
+// allowing bursts of up to `burst` requests. The limit is shared by every
+// goroutine using the client, and every attempt of a retried call counts
+// against it.
+//
+// Calls wait for the limiter unless waiting would outlast the deadline of
+// their context, in which case they fail immediately with an error matching
+// ErrRateLimited. The limiter also pauses when CircleCI responds with 429 or
+// reports that no requests remain in its own rate limit window.
+//
+// If `rps` is zero or negative the limiter never refills: the first `burst`
+// requests are let through, and every later one fails with ErrRateLimited.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *client) {
+		if burst < 1 {
+			burst = 1
+		}
+		if rps < 0 {
+			rps = 0
+		}
+		c.limiter = &limiter{
+			rate:   rps,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+}
+
+// limiter is a token bucket that is safe for concurrent use.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// No requests are let through before this time.
+	pausedUntil time.Time
+}
+
+// advance refills the bucket for the time elapsed since the last call.
+func (l *limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+}
+
+// wait blocks until a request may be made.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.advance(now)
+
+	// Reserve a token, and work out how long it takes to become available.
+	l.tokens--
+	var d time.Duration
+	if l.tokens < 0 {
+		if l.rate <= 0 {
+			l.tokens++
+			l.mu.Unlock()
+			return fmt.Errorf("%w: limit of %v requests per second", ErrRateLimited, l.rate)
+		}
+		d = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if paused := l.pausedUntil.Sub(now); paused > d {
+		d = paused
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(d).After(deadline) {
+		l.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("%w: waiting %v for the limiter would exceed the context deadline", ErrRateLimited, d)
+	}
+	l.mu.Unlock()
+
+	if err := sleep(ctx, d); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// observe adapts the limiter to the rate limit headers of `response`.
+func (l *limiter) observe(response *http.Response) {
+	now := time.Now()
+	var until time.Time
+	if response.StatusCode == http.StatusTooManyRequests {
+		if after, ok := retryAfter(response); ok {
+			until = now.Add(after)
+		}
+	}
+
+	remaining, err := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining"))
+	hasRemaining := err == nil
+	if hasRemaining && remaining == 0 {
+		if reset, ok := rateLimitReset(response, now); ok && reset.After(until) {
+			until = reset
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(now)
+	if hasRemaining && float64(remaining) < l.tokens {
+		l.tokens = float64(remaining)
+	}
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// rateLimitReset parses the X-RateLimit-Reset header of `response`, which is
+// either a Unix timestamp or a number of seconds from now.
+func rateLimitReset(response *http.Response, now time.Time) (time.Time, bool) {
+	reset, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < 0 {
+		return time.Time{}, false
+	}
+	// Anything after 2001 is a timestamp rather than a number of seconds.
+	if reset > 1e9 {
+		return time.Unix(reset, 0), true
+	}
+	return now.Add(time.Duration(reset) * time.Second), true
+}
@@ -0,0 +1,231 @@
+package circle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newLimiter returns the limiter set up by WithRateLimit(rps, burst).
+func newLimiter(rps float64, burst int) *limiter {
+	var c client
+	WithRateLimit(rps, burst)(&c)
+	return c.limiter
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l := newLimiter(50, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("burst took %v, want no wait", elapsed)
+	}
+
+	start = time.Now()
+	if err := l.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("request after the burst took %v, want about 20ms", elapsed)
+	}
+}
+
+func TestLimiterConcurrent(t *testing.T) {
+	const rps, burst, requests = 200, 5, 25
+	l := newLimiter(rps, burst)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The requests beyond the burst are spread out at the rate of the limiter.
+	want := time.Duration(requests-burst) * time.Second / rps
+	if elapsed := time.Since(start); elapsed < want*9/10 {
+		t.Errorf("%d requests took %v, want at least %v", requests, elapsed, want)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens > 1 {
+		t.Errorf("%v tokens left, want all used", l.tokens)
+	}
+}
+
+func TestLimiterDeadline(t *testing.T) {
+	l := newLimiter(1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next token takes a second, longer than the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := l.wait(ctx)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("failed after %v, want immediately", elapsed)
+	}
+
+	// The reserved token is given back.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens < -0.5 {
+		t.Errorf("%v tokens left, want about 0", l.tokens)
+	}
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	l := newLimiter(1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens < -0.5 {
+		t.Errorf("%v tokens left, want about 0", l.tokens)
+	}
+}
+
+func TestLimiterNoRate(t *testing.T) {
+	for _, rps := range []float64{0, -1} {
+		l := newLimiter(rps, 2)
+		for i := 0; i < 2; i++ {
+			if err := l.wait(context.Background()); err != nil {
+				t.Fatalf("rps %v: request %d: %v", rps, i, err)
+			}
+		}
+		for i := 0; i < 2; i++ {
+			if err := l.wait(context.Background()); !errors.Is(err, ErrRateLimited) {
+				t.Errorf("rps %v: err = %v, want ErrRateLimited", rps, err)
+			}
+		}
+	}
+}
+
+func TestLimiterPause(t *testing.T) {
+	l := newLimiter(1000, 10)
+	l.mu.Lock()
+	l.pausedUntil = time.Now().Add(30 * time.Millisecond)
+	l.mu.Unlock()
+
+	start := time.Now()
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("paused limiter let a request through after %v, want about 30ms", elapsed)
+	}
+}
+
+func TestLimiterObserve(t *testing.T) {
+	response := func(status int, header ...string) *http.Response {
+		r := &http.Response{StatusCode: status, Header: make(http.Header)}
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		response   *http.Response
+		wantPause  time.Duration
+		wantTokens float64
+	}{
+		{"no headers", response(200), 0, 10},
+		{"remaining", response(200, "X-RateLimit-Remaining", "3"), 0, 3},
+		{"remaining above tokens", response(200, "X-RateLimit-Remaining", "50"), 0, 10},
+		{"exhausted", response(200, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", "5"), 5 * time.Second, 0},
+		{"exhausted without reset", response(200, "X-RateLimit-Remaining", "0"), 0, 0},
+		{"reset with requests left", response(200, "X-RateLimit-Remaining", "1", "X-RateLimit-Reset", "5"), 0, 1},
+		{"too many requests", response(429, "Retry-After", "2"), 2 * time.Second, 10},
+		{"Retry-After without 429", response(503, "Retry-After", "2"), 0, 10},
+		{
+			"later of Retry-After and reset",
+			response(429, "Retry-After", "2", "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", "7"),
+			7 * time.Second, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(0.001, 10)
+			now := time.Now()
+			l.observe(tt.response)
+
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if tt.wantPause == 0 {
+				if !l.pausedUntil.IsZero() {
+					t.Errorf("paused until %v, want no pause", l.pausedUntil)
+				}
+			} else if d := l.pausedUntil.Sub(now); d < tt.wantPause-time.Second || d > tt.wantPause+time.Second {
+				t.Errorf("paused for %v, want %v", d, tt.wantPause)
+			}
+			if l.tokens < tt.wantTokens-0.1 || l.tokens > tt.wantTokens+0.1 {
+				t.Errorf("%v tokens, want %v", l.tokens, tt.wantTokens)
+			}
+		})
+	}
+
+	// A pause fails calls that cannot wait for it.
+	l := newLimiter(1000, 10)
+	l.observe(response(429, "Retry-After", "60"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.wait(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Time
+		ok     bool
+	}{
+		{"", time.Time{}, false},
+		{"soon", time.Time{}, false},
+		{"-1", time.Time{}, false},
+		{"0", now, true},
+		{"30", now.Add(30 * time.Second), true},
+		// Values up to 1e9 are seconds, and later ones Unix timestamps.
+		{"1000000000", now.Add(1e9 * time.Second), true},
+		{"1000000001", time.Unix(1000000001, 0), true},
+		{"1682942460", time.Unix(1682942460, 0), true},
+	}
+	for _, tt := range tests {
+		r := &http.Response{Header: http.Header{"X-Ratelimit-Reset": {tt.header}}}
+		got, ok := rateLimitReset(r, now)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("rateLimitReset(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package circle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxResponseSize bounds the size of a decoded response body.
+const defaultMaxResponseSize = 32 << 20
+
+// maxDrainSize bounds how much of an unread response body is discarded
+// before closing it so that the connection can be reused.
+const maxDrainSize = 256 << 10
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// by WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("circle: response too large")
+
+// call describes a single request to the CircleCI API.
+type call struct {
+	// Name of the CircleCI method making the call.
+	op string
+	// Arguments of the CircleCI method, keyed by parameter name.
+	params map[string]string
+	// HTTP method of the request.
+	method string
+	// Escaped path of the endpoint, relative to the API version, e.g. `/me`.
+	// See escapePath.
+	path string
+	// Query parameters of the request, if any.
+	query url.Values
+	// Value encoded as the JSON request body, if any.
+	body interface{}
+	// Additional headers of the request, if any.
+	header http.Header
+	// Absolute URL of a resource outside the API, such as an artifact, which
+	// is requested instead of `path`. The content type of its response is not
+	// checked.
+	url string
+	// Send no credentials, e.g. because `url` is pre-signed.
+	anonymous bool
+	// Error status codes that stream hands to the caller along with the
+	// response, rather than failing the call.
+	allowStatus []int
+	// The response body is handed to the caller as is, so it is requested
+	// without compression and in any media type.
+	stream bool
+}
+
+// stats records what happened during a call.
+type stats struct {
+	// Number of attempts made.
+	attempts int
+	// Status code of the final response, or 0 if there was none.
+	status int
+	// Path and query of the final request, with any credentials removed.
+	path string
+	// Number of decompressed response body bytes read.
+	bytes int64
+}
+
+// do performs `call` and decodes the JSON response into `v`, which may be nil
+// if the response has no interesting body. Every endpoint goes through do.
+func (c *client) do(ctx context.Context, call call, v interface{}) error {
+	start := time.Now()
+	var s stats
+	err := c.exec(ctx, call, v, &s)
+	c.finish(ctx, call, s, time.Since(start), err)
+	return err
+}
+
+// finish logs and measures a completed call.
+func (c *client) finish(ctx context.Context, call call, s stats, latency time.Duration, err error) {
+	c.logCall(ctx, call, s, latency, err)
+	if c.metrics != nil {
+		c.metrics.observe(call.op, s, latency, err)
+	}
+}
+
+// stream performs `call` and returns the response for the caller to read and
+// close. The call is logged and measured once the body is closed.
+func (c *client) stream(ctx context.Context, call call) (*http.Response, error) {
+	start := time.Now()
+	s := new(stats)
+
+	call.stream = true
+	response, err := c.send(ctx, call, s)
+	if err == nil {
+		s.status = response.StatusCode
+		body := &countingReader{response.Body, &s.bytes}
+		if !slices.Contains(call.allowStatus, response.StatusCode) {
+			if err = checkResponse(response, body); err != nil {
+				err = c.redact(err)
+				drainAndClose(response.Body)
+			}
+		}
+	}
+	if err != nil {
+		c.finish(ctx, call, *s, time.Since(start), err)
+		return nil, err
+	}
+
+	response.Body = &streamBody{
+		ReadCloser: response.Body,
+		reader:     &countingReader{response.Body, &s.bytes},
+		close: func() {
+			c.finish(ctx, call, *s, time.Since(start), nil)
+		},
+	}
+	return response, nil
+}
+
+// exec performs `call` for do, recording what happened in `s`.
+func (c *client) exec(ctx context.Context, call call, v interface{}, s *stats) error {
+	response, err := c.send(ctx, call, s)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(response.Body)
+	s.status = response.StatusCode
+
+	body, err := c.responseBody(response)
+	if err != nil {
+		return err
+	}
+	body = &countingReader{body, &s.bytes}
+
+	if raw := rawResponseFromContext(ctx); raw != nil {
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		*raw = RawResponse{response.StatusCode, response.Header.Clone(), b}
+		body = bytes.NewReader(b)
+	}
+
+	if err := checkResponse(response, body); err != nil {
+		return c.redact(err)
+	}
+
+	if v == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if call.url == "" {
+		if err := checkContentType(response); err != nil {
+			return err
+		}
+	}
+
+	return c.decode(call.op, body, v)
+}
+
+// escapePath joins `segments` into an absolute path, escaping each of them so
+// that it forms exactly one segment. In particular slashes within a segment,
+// as in the branch `feature/foo`, are escaped as %2F, and the segments `.` and
+// `..` are escaped so that they are not resolved as relative paths.
+func escapePath(segments ...string) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteByte('/')
+		if segment == "." || segment == ".." {
+			b.WriteString(strings.Repeat("%2E", len(segment)))
+			continue
+		}
+		b.WriteString(url.PathEscape(segment))
+	}
+	return b.String()
+}
+
+// send performs `call`, retrying it according to the client's retry policy,
+// and returns the final response. The attempts made are recorded in `s`.
+func (c *client) send(ctx context.Context, call call, s *stats) (*http.Response, error) {
+	ctx = context.WithValue(ctx, operationKey{}, Operation{call.op, call.params})
+	retry := c.retry.allows(call.method)
+	for attempt := 1; ; attempt++ {
+		s.attempts = attempt
+		request, err := c.newRequest(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		s.path = redactedPath(request.URL)
+
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := c.roundTrip(request)
+		if err == nil && response.Request == nil {
+			// Responses made up by middleware need not set their request.
+			response.Request = request
+		}
+		if c.limiter != nil && err == nil {
+			c.limiter.observe(response)
+		}
+		if !retry || attempt >= c.retry.MaxAttempts || !retryable(ctx, response, err) {
+			if err != nil {
+				return nil, c.redact(err)
+			}
+			return response, nil
+		}
+
+		wait := c.retry.backoff(attempt, response)
+		c.logRetry(ctx, call, attempt, response, c.redact(err), wait)
+		if response != nil {
+			drainAndClose(response.Body)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// newRequest creates the request for `call` with the headers every call
+// sends.
+func (c *client) newRequest(ctx context.Context, call call) (*http.Request, error) {
+	u := fmt.Sprintf("%s/%s%s", c.baseURL, c.apiVersion, call.path)
+	if call.url != "" {
+		u = call.url
+	}
+	if len(call.query) > 0 {
+		u = u + "?" + call.query.Encode()
+	}
+
+	var body io.Reader
+	if call.body != nil {
+		b, err := json.Marshal(call.body)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, call.method, u, body)
+	if err != nil {
+		return nil, c.redact(err)
+	}
+
+	for key, values := range c.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	if c.userAgent != "" {
+		request.Header.Set("User-Agent", c.userAgent)
+	}
+	// The token is sent as the basic auth username rather than in the URL so
+	// that it never appears in errors or logs. Unlike a custom header, the
+	// Authorization header is also dropped if CircleCI redirects to another
+	// host.
+	if c.token != "" && !call.anonymous {
+		request.SetBasicAuth(c.token, "")
+	}
+	if call.stream {
+		request.Header.Set("Accept", "*/*")
+		request.Header.Set("Accept-Encoding", "identity")
+	} else {
+		request.Header.Set("Accept", "application/json")
+		// Setting Accept-Encoding disables the transparent decompression of
+		// http.Transport, so gzip is handled by responseBody for any transport.
+		request.Header.Set("Accept-Encoding", "gzip")
+	}
+	if call.body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range call.header {
+		request.Header[key] = values
+	}
+
+	return request, nil
+}
+
+// responseBody returns a reader for the decompressed body of `response`,
+// limited to the client's maximum response size.
+func (c *client) responseBody(response *http.Response) (io.Reader, error) {
+	var body io.Reader = response.Body
+	if strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = gz
+	}
+	return &limitedReader{body, c.maxResponseSize}, nil
+}
+
+// checkContentType returns an error unless `response` has a JSON body.
+func checkContentType(response *http.Response) error {
+	contentType := response.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	return fmt.Errorf("circle: %s %s: unexpected content type %q", response.Request.Method, redactedPath(response.Request.URL), contentType)
+}
+
+// drainAndClose discards what is left of `body` and closes it, so that the
+// underlying connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
+	body.Close()
+}
+
+// streamBody is the body of a streamed response. It counts the bytes read and
+// calls close once when closed.
+type streamBody struct {
+	io.ReadCloser
+	reader io.Reader
+	once   sync.Once
+	close  func()
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.close)
+	return err
+}
+
+// countingReader adds the number of bytes read from r to n.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// limitedReader reads from r and fails with ErrResponseTooLarge once more
+// than n bytes have been read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
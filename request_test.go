@@ -0,0 +1,118 @@
+package circle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordingServer responds to every request with an empty JSON array, and
+// stores the request URI of the latest request in `uri`, as sent on the wire.
+func recordingServer(t *testing.T, uri *string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*uri = r.RequestURI
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRequestURL(t *testing.T) {
+	var uri string
+	srv := recordingServer(t, &uri)
+	c := New("token", WithBaseURL(srv.URL))
+	ctx := context.Background()
+	p := GitHubProject("org", "repo")
+	filter := func(s string) RecentBuildsOptions { return RecentBuildsOptions{Filter: &s} }
+	limit, failed := 5, "failed"
+
+	tests := []struct {
+		name string
+		call func()
+		want string
+	}{
+		{
+			"branch with slash, hash and space",
+			func() { c.BuildsForBranch(ctx, p, "feature/foo#1 x", RecentBuildsOptions{}) },
+			"/v1.1/project/github/org/repo/tree/feature%2Ffoo%231%20x",
+		},
+		{
+			"pull request branch",
+			func() { c.BuildsForBranch(ctx, p, "pull/346", RecentBuildsOptions{}) },
+			"/v1.1/project/github/org/repo/tree/pull%2F346",
+		},
+		{
+			"branch with spaces",
+			func() { c.BuildsForBranch(ctx, p, "my branch", RecentBuildsOptions{}) },
+			"/v1.1/project/github/org/repo/tree/my%20branch",
+		},
+		{
+			"branch with query characters",
+			func() { c.BuildsForBranch(ctx, p, "a?b=c&d", RecentBuildsOptions{}) },
+			"/v1.1/project/github/org/repo/tree/a%3Fb=c&d",
+		},
+		{
+			"legacy branch",
+			func() { c.RecentBuildsForProjectBranch("org", "repo", "pull/346", RecentBuildsOptions{}) },
+			"/v1.1/project/github/org/repo/tree/pull%2F346",
+		},
+		{
+			"trigger branch",
+			func() { c.TriggerBuild(ctx, p, TriggerBuildOptions{Branch: "feature/foo#1 x"}) },
+			"/v1.1/project/github/org/repo/tree/feature%2Ffoo%231%20x",
+		},
+		{
+			"filter with ampersand and equals",
+			func() { c.BuildsForProject(ctx, p, filter("completed&x=1")) },
+			"/v1.1/project/github/org/repo?filter=completed%26x%3D1",
+		},
+		{
+			"filter and limit",
+			func() { c.BuildsForProject(ctx, p, RecentBuildsOptions{Filter: &failed, Limit: &limit}) },
+			"/v1.1/project/github/org/repo?filter=failed&limit=5",
+		},
+		{
+			"branch and filter",
+			func() { c.BuildsForBranch(ctx, p, "pull/346", filter("running")) },
+			"/v1.1/project/github/org/repo/tree/pull%2F346?filter=running",
+		},
+		{
+			"dot segments",
+			func() { c.BuildsForBranch(ctx, p, "..", RecentBuildsOptions{}) },
+			"/v1.1/project/github/org/repo/tree/%2E%2E",
+		},
+		{
+			"org and repo",
+			func() { c.GetBuild(ctx, BitbucketProject("my org", "repo#1"), 7) },
+			"/v1.1/project/bitbucket/my%20org/repo%231/7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri = ""
+			tt.call()
+			if uri != tt.want {
+				t.Errorf("requested %s, want %s", uri, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct {
+		segments []string
+		want     string
+	}{
+		{[]string{"me"}, "/me"},
+		{[]string{"project", "github", "org", "repo", "tree", "feature/foo"}, "/project/github/org/repo/tree/feature%2Ffoo"},
+		{[]string{"a b", "c#d", "e?f", "g%h"}, "/a%20b/c%23d/e%3Ff/g%25h"},
+		{[]string{"..", ".", "..."}, "/%2E%2E/%2E/..."},
+	}
+	for _, tt := range tests {
+		if got := escapePath(tt.segments...); got != tt.want {
+			t.Errorf("escapePath(%q) = %s, want %s", tt.segments, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package circle
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed calls are retried. Calls are retried after
+// connection errors and after 429, 502, 503 and 504 responses.
+type RetryPolicy struct {
+	// Maximum number of attempts per call, including the first. Values below 2
+	// disable retries.
+	MaxAttempts int
+	// Backoff before the first retry. It doubles for every further retry, and
+	// a random jitter of up to half of it is applied.
+	MinBackoff time.Duration
+	// Upper bound on the backoff between attempts, or 0 for no bound. It does
+	// not bound the wait requested by a Retry-After header.
+	MaxBackoff time.Duration
+	// Also retry calls that change state on CircleCI, such as Retry, Cancel,
+	// Build and ClearCache. These may then take effect more than once.
+	RetryMutating bool
+}
+
+// DefaultRetryPolicy is the retry policy of clients created without
+// WithRetryPolicy. It retries read-only calls only.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+}
+
+// WithRetryPolicy sets the policy used to retry failed calls. Use
+// `RetryPolicy{}` to disable retries.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *client) {
+		c.retry = p
+	}
+}
+
+// allows reports whether calls using `method` may be retried.
+func (p RetryPolicy) allows(method string) bool {
+	if p.MaxAttempts < 2 {
+		return false
+	}
+	return method == "GET" || method == "HEAD" || p.RetryMutating
+}
+
+// backoff returns how long to wait after the given failed attempt, honouring
+// the Retry-After header of `response` if there is one.
+func (p RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d <= math.MaxInt64/2; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+
+	if response != nil {
+		if after, ok := retryAfter(response); ok && after > d {
+			d = after
+		}
+	}
+	return d
+}
+
+// retryable reports whether an attempt that ended with `response` or `err`
+// should be retried.
+func retryable(ctx context.Context, response *http.Response, err error) bool {
+	if err != nil {
+		// Errors caused by the caller's context are final.
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header of `response`, which is either a
+// number of seconds or an HTTP date.
+func retryAfter(response *http.Response) (time.Duration, bool) {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// sleep waits for `d`, returning early with the context's error if it is done
+// first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,155 @@
+package circle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		min, max time.Duration
+	}{
+		{"first", RetryPolicy{MinBackoff: time.Second, MaxBackoff: time.Minute}, 1, 500 * time.Millisecond, time.Second},
+		{"doubles", RetryPolicy{MinBackoff: time.Second, MaxBackoff: time.Minute}, 3, 2 * time.Second, 4 * time.Second},
+		{"capped", RetryPolicy{MinBackoff: time.Second, MaxBackoff: 3 * time.Second}, 5, 1500 * time.Millisecond, 3 * time.Second},
+		{"no cap first", RetryPolicy{MinBackoff: time.Second}, 1, 500 * time.Millisecond, time.Second},
+		{"no cap", RetryPolicy{MinBackoff: time.Second}, 3, 2 * time.Second, 4 * time.Second},
+		{"no cap overflow", RetryPolicy{MinBackoff: time.Second}, 100, 1 << 61, 1<<63 - 1},
+		{"no backoff", RetryPolicy{}, 3, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				d := tt.policy.backoff(tt.attempt, nil)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 2 * time.Second}
+	response := &http.Response{Header: http.Header{"Retry-After": {"10"}}}
+	if d := policy.backoff(1, response); d != 10*time.Second {
+		t.Errorf("backoff = %v, want 10s", d)
+	}
+
+	response.Header.Set("Retry-After", "invalid")
+	if d := policy.backoff(1, response); d > time.Second {
+		t.Errorf("backoff = %v, want at most 1s", d)
+	}
+}
+
+// failingServer responds to the first `failures` requests with `status`, and
+// to later ones with an empty JSON object. It counts requests in `n`.
+func failingServer(t *testing.T, failures, status int, header http.Header, n *int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*n++
+		if *n <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	tests := []struct {
+		name     string
+		status   int
+		failures int
+		wantErr  bool
+		wantN    int
+	}{
+		{"recovers", http.StatusServiceUnavailable, 2, false, 3},
+		{"gives up", http.StatusBadGateway, 3, true, 3},
+		{"not retryable", http.StatusInternalServerError, 1, true, 1},
+		{"not found", http.StatusNotFound, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n int
+			srv := failingServer(t, tt.failures, tt.status, nil, &n)
+			c := New("token", WithBaseURL(srv.URL), WithRetryPolicy(policy))
+			_, err := c.MeContext(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("made %d requests, want %d", n, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	var n int
+	header := http.Header{"Retry-After": {"1"}}
+	srv := failingServer(t, 1, http.StatusTooManyRequests, header, &n)
+	c := New("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}))
+
+	start := time.Now()
+	if _, err := c.MeContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least 1s", elapsed)
+	}
+	if n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestRetryMutating(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryMutating bool
+		wantN         int
+	}{
+		{"default", false, 1},
+		{"opted in", true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n int
+			srv := failingServer(t, 1, http.StatusServiceUnavailable, nil, &n)
+			policy := RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, RetryMutating: tt.retryMutating}
+			c := New("token", WithBaseURL(srv.URL), WithRetryPolicy(policy))
+			c.RetryBuild(context.Background(), GitHubProject("org", "repo"), 1)
+			if n != tt.wantN {
+				t.Errorf("made %d requests, want %d", n, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	var n int
+	header := http.Header{"Retry-After": {"60"}}
+	srv := failingServer(t, 1, http.StatusServiceUnavailable, header, &n)
+	c := New("token", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.MeContext(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n != 1 {
+		t.Errorf("made %d requests, want 1", n)
+	}
+}
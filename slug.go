@@ -0,0 +1,157 @@
+package circle
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// VCSType is the version control service hosting a project.
+type VCSType string
+
+// Version control services supported by CircleCI.
+const (
+	VCSGitHub    VCSType = "github"
+	VCSBitbucket VCSType = "bitbucket"
+)
+
+// ProjectSlug identifies a project by its version control service,
+// organization (or user) and repository.
+type ProjectSlug struct {
+	VCSType VCSType
+	Org     string
+	Repo    string
+}
+
+// GitHubProject returns the slug of the GitHub repository `org/repo`.
+func GitHubProject(org, repo string) ProjectSlug {
+	return ProjectSlug{VCSGitHub, org, repo}
+}
+
+// BitbucketProject returns the slug of the Bitbucket repository `org/repo`.
+func BitbucketProject(org, repo string) ProjectSlug {
+	return ProjectSlug{VCSBitbucket, org, repo}
+}
+
+// ParseProjectSlug parses a project slug such as `gh/org/repo`,
+// `github/org/repo`, `bb/org/repo` or `bitbucket/org/repo`, or the URL of a
+// repository such as `https://github.com/org/repo` (as found in
+// BuildSummary.VCSURL) or `git@bitbucket.org:org/repo.git`. Repositories on
+// hosts other than bitbucket.org are taken to be on GitHub, which includes
+// the GitHub Enterprise installs CircleCI Server builds from.
+func ParseProjectSlug(s string) (ProjectSlug, error) {
+	if strings.Contains(s, "://") || strings.HasPrefix(s, "git@") {
+		return parseVCSURL(s)
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return ProjectSlug{}, fmt.Errorf("circle: invalid project slug %q", s)
+	}
+	vcs, ok := parseVCSType(parts[0])
+	if !ok {
+		return ProjectSlug{}, fmt.Errorf("circle: invalid project slug %q: unknown VCS type %q", s, parts[0])
+	}
+	p := ProjectSlug{vcs, parts[1], parts[2]}
+	if err := p.validate(); err != nil {
+		return ProjectSlug{}, err
+	}
+	return p, nil
+}
+
+// parseVCSURL parses the URL of a repository.
+func parseVCSURL(s string) (ProjectSlug, error) {
+	var host, path string
+	if rest, ok := strings.CutPrefix(s, "git@"); ok {
+		host, path, _ = strings.Cut(rest, ":")
+	} else {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ProjectSlug{}, fmt.Errorf("circle: invalid repository URL %q: %v", s, err)
+		}
+		host, path = u.Hostname(), u.Path
+	}
+
+	if host == "" {
+		return ProjectSlug{}, fmt.Errorf("circle: invalid repository URL %q: missing host", s)
+	}
+	vcs := VCSGitHub
+	if strings.EqualFold(strings.TrimPrefix(host, "www."), "bitbucket.org") {
+		vcs = VCSBitbucket
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.Trim(path, "/"), ".git"), "/")
+	if len(parts) != 2 {
+		return ProjectSlug{}, fmt.Errorf("circle: invalid repository URL %q", s)
+	}
+	p := ProjectSlug{vcs, parts[0], parts[1]}
+	if err := p.validate(); err != nil {
+		return ProjectSlug{}, err
+	}
+	return p, nil
+}
+
+// parseVCSType parses the short or long name of a VCS type.
+func parseVCSType(s string) (VCSType, bool) {
+	switch strings.ToLower(s) {
+	case "gh", "github":
+		return VCSGitHub, true
+	case "bb", "bitbucket":
+		return VCSBitbucket, true
+	}
+	return "", false
+}
+
+func (p ProjectSlug) validate() error {
+	if p.Org == "" || p.Repo == "" {
+		return fmt.Errorf("circle: invalid project slug %q: missing organization or repository", p.String())
+	}
+	return nil
+}
+
+// String returns the slug in its short form, e.g. `gh/org/repo`.
+func (p ProjectSlug) String() string {
+	vcs := string(p.VCSType)
+	switch p.VCSType {
+	case VCSGitHub:
+		vcs = "gh"
+	case VCSBitbucket:
+		vcs = "bb"
+	}
+	return vcs + "/" + p.Org + "/" + p.Repo
+}
+
+// path returns the API path of the project followed by `segments`, e.g.
+// `/project/github/org/repo/tree/master`. Every segment is escaped.
+func (p ProjectSlug) path(segments ...string) string {
+	return escapePath(append([]string{"project", string(p.VCSType), p.Org, p.Repo}, segments...)...)
+}
+
+// params returns the Operation parameters identifying the project, i.e.
+// "project" holding its short form.
+func (p ProjectSlug) params() map[string]string {
+	return map[string]string{"project": p.String()}
+}
+
+// legacyParams returns the Operation parameters identifying a project for the
+// original methods that take a GitHub `username` and `project`.
+func legacyParams(username, project string) map[string]string {
+	return map[string]string{"username": username, "project": project}
+}
+
+// Slug returns the slug of the project the build belongs to, parsed from its
+// VCSURL.
+func (b BuildSummary) Slug() (ProjectSlug, error) {
+	return ParseProjectSlug(b.VCSURL)
+}
+
+// Slug returns the slug of the project the build belongs to, parsed from its
+// VCSURL.
+func (b Build) Slug() (ProjectSlug, error) {
+	return ParseProjectSlug(b.VCSURL)
+}
+
+// Slug returns the slug of the project, parsed from its VCSURL.
+func (p Project) Slug() (ProjectSlug, error) {
+	return ParseProjectSlug(p.VCSURL)
+}
@@ -0,0 +1,61 @@
+package circle
+
+import "testing"
+
+func TestParseProjectSlug(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ProjectSlug
+	}{
+		{"gh/org/repo", GitHubProject("org", "repo")},
+		{"github/org/repo", GitHubProject("org", "repo")},
+		{"bb/org/repo", BitbucketProject("org", "repo")},
+		{"bitbucket/org/repo", BitbucketProject("org", "repo")},
+		{"https://github.com/org/repo", GitHubProject("org", "repo")},
+		{"https://www.github.com/org/repo/", GitHubProject("org", "repo")},
+		{"https://bitbucket.org/org/repo", BitbucketProject("org", "repo")},
+		{"git@bitbucket.org:org/repo.git", BitbucketProject("org", "repo")},
+		{"git@github.com:org/repo.git", GitHubProject("org", "repo")},
+		{"https://ghe.example.com/org/repo", GitHubProject("org", "repo")},
+		{"https://ghe.example.com:8443/org/repo.git", GitHubProject("org", "repo")},
+		{"git@ghe.example.com:org/repo.git", GitHubProject("org", "repo")},
+	}
+	for _, tt := range tests {
+		got, err := ParseProjectSlug(tt.in)
+		if err != nil {
+			t.Errorf("ParseProjectSlug(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseProjectSlug(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseProjectSlugInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"org/repo",
+		"svn/org/repo",
+		"gh/org/",
+		"https://github.com/org",
+		"https://github.com/org/repo/tree/main",
+		"https:///org/repo",
+		"git@:org/repo",
+	} {
+		if p, err := ParseProjectSlug(in); err == nil {
+			t.Errorf("ParseProjectSlug(%q) = %v, want an error", in, p)
+		}
+	}
+}
+
+func TestBuildSummarySlugEnterprise(t *testing.T) {
+	b := BuildSummary{VCSURL: "https://github.example.com/org/repo"}
+	p, err := b.Slug()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := GitHubProject("org", "repo"); p != want {
+		t.Errorf("Slug = %v, want %v", p, want)
+	}
+}
@@ -0,0 +1,93 @@
+package circle
+
+// Status of a build, as reported in BuildSummary.Status and friends.
+type Status string
+
+// Build statuses reported by CircleCI.
+const (
+	StatusQueued             Status = "queued"
+	StatusScheduled          Status = "scheduled"
+	StatusNotRunning         Status = "not_running"
+	StatusRunning            Status = "running"
+	StatusNotRun             Status = "not_run"
+	StatusSuccess            Status = "success"
+	StatusFixed              Status = "fixed"
+	StatusNoTests            Status = "no_tests"
+	StatusFailed             Status = "failed"
+	StatusTimedout           Status = "timedout"
+	StatusCanceled           Status = "canceled"
+	StatusInfrastructureFail Status = "infrastructure_fail"
+	StatusRetried            Status = "retried"
+)
+
+// IsTerminal reports whether a build with this status has stopped and will
+// not change status again.
+func (s Status) IsTerminal() bool {
+	switch s {
+	case StatusNotRun, StatusSuccess, StatusFixed, StatusNoTests, StatusFailed,
+		StatusTimedout, StatusCanceled, StatusInfrastructureFail, StatusRetried:
+		return true
+	}
+	return false
+}
+
+// IsGreen reports whether a build with this status passed.
+func (s Status) IsGreen() bool {
+	return s == StatusSuccess || s == StatusFixed
+}
+
+// IsRunning reports whether a build with this status is running.
+func (s Status) IsRunning() bool {
+	return s == StatusRunning
+}
+
+// Lifecycle is the stage a build is at.
+type Lifecycle string
+
+// Build lifecycles reported by CircleCI.
+const (
+	LifecycleQueued     Lifecycle = "queued"
+	LifecycleScheduled  Lifecycle = "scheduled"
+	LifecycleNotRunning Lifecycle = "not_running"
+	LifecycleRunning    Lifecycle = "running"
+	LifecycleNotRun     Lifecycle = "not_run"
+	LifecycleFinished   Lifecycle = "finished"
+)
+
+// IsTerminal reports whether a build at this stage has stopped.
+func (l Lifecycle) IsTerminal() bool {
+	return l == LifecycleFinished || l == LifecycleNotRun
+}
+
+// IsRunning reports whether a build at this stage is running.
+func (l Lifecycle) IsRunning() bool {
+	return l == LifecycleRunning
+}
+
+// Outcome of a finished build. It is empty until the build finishes.
+type Outcome string
+
+// Build outcomes reported by CircleCI.
+const (
+	OutcomeSuccess            Outcome = "success"
+	OutcomeNoTests            Outcome = "no_tests"
+	OutcomeFailed             Outcome = "failed"
+	OutcomeTimedout           Outcome = "timedout"
+	OutcomeCanceled           Outcome = "canceled"
+	OutcomeInfrastructureFail Outcome = "infrastructure_fail"
+)
+
+// IsTerminal reports whether the build has an outcome, i.e. has finished.
+func (o Outcome) IsTerminal() bool {
+	return o != ""
+}
+
+// IsGreen reports whether the build passed.
+func (o Outcome) IsGreen() bool {
+	return o == OutcomeSuccess
+}
+
+// IsRunning always reports false: a build with an outcome has finished.
+func (o Outcome) IsRunning() bool {
+	return false
+}
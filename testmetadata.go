@@ -0,0 +1,118 @@
+package circle
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Result of a test case, as reported in TestResult.Result.
+type TestResultStatus string
+
+// Test results reported by CircleCI.
+const (
+	TestSuccess TestResultStatus = "success"
+	TestFailure TestResultStatus = "failure"
+	TestSkipped TestResultStatus = "skipped"
+)
+
+// The result of a single test case, parsed by CircleCI from the JUnit reports
+// of a build.
+type TestResult struct {
+	Classname string `json:"classname"`
+	Name      string `json:"name"`
+	File      string `json:"file"`
+	// One of TestSuccess, TestFailure or TestSkipped.
+	Result TestResultStatus `json:"result"`
+	// Run time in seconds.
+	RunTime float64 `json:"run_time"`
+	// The failure message, if any.
+	Message *string `json:"message"`
+	// The tool the test results were collected from, e.g. `junit`.
+	Source string `json:"source"`
+}
+
+// Duration returns how long the test ran for.
+func (t TestResult) Duration() time.Duration {
+	return time.Duration(t.RunTime * float64(time.Second))
+}
+
+// IsFailed reports whether the test failed.
+func (t TestResult) IsFailed() bool {
+	return t.Result == TestFailure
+}
+
+func (c *client) TestMetadata(ctx context.Context, p ProjectSlug, num int) ([]TestResult, error) {
+	var m testMetadataResponse
+	err := c.do(ctx, call{
+		op:     "TestMetadata",
+		method: "GET",
+		path:   p.path(strconv.Itoa(num), "tests"),
+		params: map[string]string{
+			"project": p.String(),
+			"num":     strconv.Itoa(num),
+		},
+	}, &m)
+	if err != nil {
+		return make([]TestResult, 0), err
+	}
+
+	return m.Tests, nil
+}
+
+// testMetadataResponse is the body of a TestMetadata response.
+type testMetadataResponse struct {
+	Tests []TestResult `json:"tests"`
+}
+
+// FailedTests returns the tests in `tests` that failed.
+func FailedTests(tests []TestResult) []TestResult {
+	failed := make([]TestResult, 0)
+	for _, t := range tests {
+		if t.IsFailed() {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+// Results of the tests of a single class.
+type TestClassSummary struct {
+	Classname string
+	Tests     int
+	Failures  int
+	Skipped   int
+	// Total run time of the tests.
+	RunTime time.Duration
+}
+
+// SummarizeByClass aggregates `tests` by their class, and returns a summary of
+// each class ordered by name.
+func SummarizeByClass(tests []TestResult) []TestClassSummary {
+	classes := make(map[string]*TestClassSummary)
+	for _, t := range tests {
+		s, ok := classes[t.Classname]
+		if !ok {
+			s = &TestClassSummary{Classname: t.Classname}
+			classes[t.Classname] = s
+		}
+		s.Tests++
+		switch t.Result {
+		case TestFailure:
+			s.Failures++
+		case TestSkipped:
+			s.Skipped++
+		}
+		s.RunTime += t.Duration()
+	}
+
+	summaries := make([]TestClassSummary, 0, len(classes))
+	for _, s := range classes {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Classname < summaries[j].Classname
+	})
+	return summaries
+}
@@ -0,0 +1,82 @@
+package circle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTestMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.1/project/github/org/repo/12/tests" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"tests":[
+			{"classname":"pkg.A","name":"passes","file":"a_test.go","result":"success","run_time":0.25,"message":null,"source":"junit"},
+			{"classname":"pkg.A","name":"fails","file":"a_test.go","result":"failure","run_time":1.5,"message":"boom","source":"junit"}
+		]}`))
+	}))
+	defer srv.Close()
+	c := New("token", WithBaseURL(srv.URL))
+
+	tests, err := c.TestMetadata(context.Background(), GitHubProject("org", "repo"), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TestResult{
+		{Classname: "pkg.A", Name: "passes", File: "a_test.go", Result: TestSuccess, RunTime: 0.25, Source: "junit"},
+		{Classname: "pkg.A", Name: "fails", File: "a_test.go", Result: TestFailure, RunTime: 1.5, Message: stringPtr("boom"), Source: "junit"},
+	}
+	if !reflect.DeepEqual(tests, want) {
+		t.Errorf("tests = %+v, want %+v", tests, want)
+	}
+	if d := tests[1].Duration(); d != 1500*time.Millisecond {
+		t.Errorf("Duration() = %v, want 1.5s", d)
+	}
+}
+
+func TestFailedTests(t *testing.T) {
+	tests := []TestResult{
+		{Name: "a", Result: TestSuccess},
+		{Name: "b", Result: TestFailure},
+		{Name: "c", Result: TestSkipped},
+		{Name: "d", Result: TestFailure},
+	}
+	want := []TestResult{tests[1], tests[3]}
+	if got := FailedTests(tests); !reflect.DeepEqual(got, want) {
+		t.Errorf("FailedTests() = %+v, want %+v", got, want)
+	}
+
+	for _, tests := range [][]TestResult{nil, {{Name: "a", Result: TestSuccess}}} {
+		if got := FailedTests(tests); got == nil || len(got) != 0 {
+			t.Errorf("FailedTests(%+v) = %#v, want an empty slice", tests, got)
+		}
+	}
+}
+
+func TestSummarizeByClass(t *testing.T) {
+	tests := []TestResult{
+		{Classname: "pkg.B", Result: TestSuccess, RunTime: 0.5},
+		{Classname: "pkg.A", Result: TestFailure, RunTime: 1},
+		{Classname: "pkg.B", Result: TestSkipped},
+		{Classname: "pkg.A", Result: TestSuccess, RunTime: 0.25},
+		{Classname: "pkg.B", Result: TestFailure, RunTime: 2},
+		{Classname: "", Result: TestSuccess, RunTime: 0.125},
+	}
+	want := []TestClassSummary{
+		{Classname: "", Tests: 1, RunTime: 125 * time.Millisecond},
+		{Classname: "pkg.A", Tests: 2, Failures: 1, RunTime: 1250 * time.Millisecond},
+		{Classname: "pkg.B", Tests: 3, Failures: 1, Skipped: 1, RunTime: 2500 * time.Millisecond},
+	}
+	if got := SummarizeByClass(tests); !reflect.DeepEqual(got, want) {
+		t.Errorf("SummarizeByClass() = %+v, want %+v", got, want)
+	}
+
+	if got := SummarizeByClass(nil); got == nil || len(got) != 0 {
+		t.Errorf("SummarizeByClass(nil) = %#v, want an empty slice", got)
+	}
+}
@@ -0,0 +1,85 @@
+package circle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Time is a timestamp reported by CircleCI.
+//
+// It decodes from RFC 3339 strings with or without fractional seconds and
+// with any UTC offset, and from numbers of milliseconds since the Unix epoch.
+// null and empty strings leave it zero.
+//
+// A decoded Time encodes as the JSON it was decoded from for as long as it
+// holds the same instant, so that responses survive a round trip unchanged.
+// Otherwise a zero Time encodes as null and others as RFC 3339 strings.
+type Time struct {
+	time.Time
+
+	// The JSON the time was decoded from, and the instant it decoded to.
+	raw     string
+	decoded time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	t.raw, t.decoded = "", time.Time{}
+	if err := t.parse(b); err != nil {
+		return err
+	}
+	t.raw, t.decoded = string(b), t.Time
+	return nil
+}
+
+// parse sets the time to the instant the JSON value `b` describes.
+func (t *Time) parse(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	if len(b) > 0 && b[0] != '"' {
+		ms, err := strconv.ParseInt(string(b), 10, 64)
+		if err != nil {
+			return fmt.Errorf("circle: invalid timestamp %s", b)
+		}
+		t.Time = time.UnixMilli(ms).UTC()
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("circle: invalid timestamp %q: %v", s, err)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.raw != "" && t.Time.Equal(t.decoded) {
+		return []byte(t.raw), nil
+	}
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Time.Format(time.RFC3339Nano))
+}
+
+// millis converts a number of milliseconds reported by CircleCI to a duration.
+func millis(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
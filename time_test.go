@@ -0,0 +1,72 @@
+package circle
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want time.Time
+	}{
+		{"milliseconds", `"2015-10-30T12:00:00.000Z"`, time.Date(2015, 10, 30, 12, 0, 0, 0, time.UTC)},
+		{"seconds", `"2015-10-30T12:00:00Z"`, time.Date(2015, 10, 30, 12, 0, 0, 0, time.UTC)},
+		{"offset", `"2015-10-30T13:00:00+01:00"`, time.Date(2015, 10, 30, 12, 0, 0, 0, time.UTC)},
+		{"epoch milliseconds", `1446206400000`, time.Date(2015, 10, 30, 12, 0, 0, 0, time.UTC)},
+		{"empty", `""`, time.Time{}},
+		{"null", `null`, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Time
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("decoded %v, want %v", got.Time, tt.want)
+			}
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("encoded %s, want %s", b, tt.json)
+			}
+		})
+	}
+}
+
+func TestTimeMarshalChanged(t *testing.T) {
+	var tm Time
+	if err := json.Unmarshal([]byte(`1446206400000`), &tm); err != nil {
+		t.Fatal(err)
+	}
+	tm.Time = tm.Add(time.Second)
+	b, err := json.Marshal(tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"2015-10-30T12:00:01Z"`; string(b) != want {
+		t.Errorf("encoded %s, want %s", b, want)
+	}
+
+	b, err = json.Marshal(Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "null" {
+		t.Errorf("encoded zero Time as %s, want null", b)
+	}
+}
+
+func TestTimeInvalid(t *testing.T) {
+	for _, s := range []string{`"yesterday"`, `1.5`, `true`} {
+		var tm Time
+		if err := json.Unmarshal([]byte(s), &tm); err == nil {
+			t.Errorf("decoding %s: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package circle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Models keep the members of JSON objects that this package does not know
+// about in their Unknown field, and write them back when marshalled, so that
+// responses survive a round trip through the package without losing data.
+// They also record which known members were present, and which of those were
+// null, so that members absent from a response are not added when it is
+// written back, and null members are not replaced by zero values.
+//
+// unmarshalModel and marshalModel implement this by handling the fields of a
+// model one by one. This is needed because a model such as
+// DetailedBuildSummary embeds another model, whose methods would otherwise be
+// promoted and used for the whole object.
+
+// jsonMembers records the known members of a decoded JSON object, mapping the
+// name of each to whether it was null.
+type jsonMembers map[string]bool
+
+// unmarshalModel decodes the JSON object `data` into the struct pointed to by
+// `v`, storing members that match none of its fields in `unknown` and
+// recording the others in `known`.
+func unmarshalModel(data []byte, v interface{}, unknown *map[string]json.RawMessage, known *jsonMembers) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	fields := modelFields(rv.Type())
+	*unknown = nil
+	*known = make(jsonMembers, len(members))
+	for name, raw := range members {
+		f, ok := fields.lookup(name)
+		if !ok {
+			if *unknown == nil {
+				*unknown = make(map[string]json.RawMessage)
+			}
+			(*unknown)[name] = raw
+			continue
+		}
+		(*known)[f.name] = bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		if err := json.Unmarshal(raw, rv.FieldByIndex(f.index).Addr().Interface()); err != nil {
+			return fmt.Errorf("circle: decoding %s.%s: %w", rv.Type().Name(), f.name, err)
+		}
+	}
+	return nil
+}
+
+// marshalModel encodes the struct pointed to by `v` as a JSON object,
+// followed by the members in `unknown`. If `known` is not nil, fields that
+// are still zero are written as they were decoded: left out if their member
+// was absent, and null if it was null.
+func marshalModel(v interface{}, unknown map[string]json.RawMessage, known jsonMembers) ([]byte, error) {
+	rv := reflect.ValueOf(v).Elem()
+	fields := modelFields(rv.Type())
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(name string, value []byte) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	for _, f := range fields.list {
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if known != nil && fv.IsZero() {
+			null, ok := known[f.name]
+			if !ok {
+				continue
+			}
+			if null {
+				write(f.name, []byte("null"))
+				continue
+			}
+		}
+		value, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		write(f.name, value)
+	}
+
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		if _, ok := fields.byName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write(name, unknown[name])
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// modelField is a field of a model as seen by encoding/json.
+type modelField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type modelFieldSet struct {
+	list   []modelField
+	byName map[string]modelField
+}
+
+// lookup finds the field for the JSON member `name`, preferring an exact
+// match but otherwise matching case-insensitively like encoding/json.
+func (s modelFieldSet) lookup(name string) (modelField, bool) {
+	if f, ok := s.byName[name]; ok {
+		return f, true
+	}
+	for _, f := range s.list {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return modelField{}, false
+}
+
+var modelFieldCache sync.Map // map[reflect.Type]modelFieldSet
+
+// modelFields returns the JSON fields of the struct type `t`, including those
+// of embedded structs.
+func modelFields(t reflect.Type) modelFieldSet {
+	if s, ok := modelFieldCache.Load(t); ok {
+		return s.(modelFieldSet)
+	}
+
+	// Collect candidate fields in declaration order, noting how deeply they
+	// are embedded: shallower fields take precedence, like in encoding/json.
+	type candidate struct {
+		modelField
+		depth int
+	}
+	var candidates []candidate
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			fieldIndex := append(append([]int(nil), index...), i)
+			if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+				walk(sf.Type, fieldIndex)
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			candidates = append(candidates, candidate{modelField{name, fieldIndex, opts == "omitempty"}, len(index)})
+		}
+	}
+	walk(t, nil)
+
+	depth := make(map[string]int)
+	for _, c := range candidates {
+		if d, ok := depth[c.name]; !ok || c.depth < d {
+			depth[c.name] = c.depth
+		}
+	}
+	s := modelFieldSet{byName: make(map[string]modelField)}
+	for _, c := range candidates {
+		if _, ok := s.byName[c.name]; ok || c.depth != depth[c.name] {
+			continue
+		}
+		s.list = append(s.list, c.modelField)
+		s.byName[c.name] = c.modelField
+	}
+
+	modelFieldCache.Store(t, s)
+	return s
+}
+
+// isEmptyValue reports whether `v` is empty in the sense of the omitempty
+// option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// RawResponse holds the raw response of a call, as captured by
+// CaptureRawResponse.
+type RawResponse struct {
+	StatusCode int
+	Header     http.Header
+	// Decompressed response body.
+	Body []byte
+}
+
+type rawResponseKey struct{}
+
+// CaptureRawResponse returns a context that makes the client store the
+// response of calls made with it in `raw`, e.g. so that the response can be
+// stored and replayed verbatim. If a call is retried, `raw` holds the final
+// response.
+func CaptureRawResponse(ctx context.Context, raw *RawResponse) context.Context {
+	return context.WithValue(ctx, rawResponseKey{}, raw)
+}
+
+func rawResponseFromContext(ctx context.Context) *RawResponse {
+	raw, _ := ctx.Value(rawResponseKey{}).(*RawResponse)
+	return raw
+}
@@ -0,0 +1,93 @@
+package circle
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual reports whether `a` and `b` hold the same JSON value, regardless
+// of the order of object members and of whitespace.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	for _, x := range []struct {
+		data []byte
+		v    *interface{}
+	}{{a, &va}, {b, &vb}} {
+		d := json.NewDecoder(bytes.NewReader(x.data))
+		d.UseNumber()
+		if err := d.Decode(x.v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestModelRoundTrip(t *testing.T) {
+	tests := []struct {
+		fixture string
+		v       interface{}
+	}{
+		{"me.json", new(Me)},
+		{"project.json", new(Project)},
+		{"build_summaries.json", new([]BuildSummary)},
+		{"detailed_build_summary.json", new(DetailedBuildSummary)},
+		{"build.json", new(Build)},
+		// Nested models with unknown, absent and null members.
+		{"me_sparse.json", new(Me)},
+		{"project_sparse.json", new(Project)},
+		{"build_summary_sparse.json", new(BuildSummary)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, tt.v); err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, data, b) {
+				t.Errorf("re-encoded as\n%s\nwant\n%s", b, data)
+			}
+		})
+	}
+}
+
+func TestModelMarshalChanged(t *testing.T) {
+	var b Build
+	if err := json.Unmarshal([]byte(`{"build_num":1,"why":null,"subject":"x","extra":[1]}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	b.Why = "retry"
+	b.Branch = "master"
+	b.Subject = ""
+
+	got, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"branch":"master","build_num":1,"subject":"","why":"retry","extra":[1]}`
+	if !jsonEqual(t, got, []byte(want)) {
+		t.Errorf("encoded %s, want %s", got, want)
+	}
+}
+
+func TestModelMarshalConstructed(t *testing.T) {
+	got, err := json.Marshal(Artifact{Path: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"node_index":0,"path":"a","pretty_path":"","url":""}`
+	if !jsonEqual(t, got, []byte(want)) {
+		t.Errorf("encoded %s, want %s", got, want)
+	}
+}